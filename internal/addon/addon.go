@@ -1,6 +1,9 @@
 package addon
 
 import (
+	"strings"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
 	"open-cluster-management.io/addon-framework/pkg/agent"
 	"open-cluster-management.io/addon-framework/pkg/utils"
 	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
@@ -30,3 +33,43 @@ func GetObjectKey(configRef []addonapiv1alpha1.ConfigReference, group, resource
 	}
 	return key
 }
+
+// GetObjectKeys returns the keys of all the config references of the
+// resource, in the order they are listed.
+func GetObjectKeys(configRef []addonapiv1alpha1.ConfigReference, group, resource string) []client.ObjectKey {
+	keys := []client.ObjectKey{}
+	for _, config := range configRef {
+		if config.ConfigGroupResource.Group != group {
+			continue
+		}
+		if config.ConfigGroupResource.Resource != resource {
+			continue
+		}
+
+		keys = append(keys, client.ObjectKey{Name: config.Name, Namespace: config.Namespace})
+	}
+	return keys
+}
+
+// CustomizedVariable returns the value of the AddOnDeploymentConfig variable
+// or an empty string if it's not set.
+func CustomizedVariable(adoc *addonapiv1alpha1.AddOnDeploymentConfig, name string) string {
+	if adoc == nil {
+		return ""
+	}
+	for _, keyvalue := range adoc.Spec.CustomizedVariables {
+		if keyvalue.Name == name {
+			return keyvalue.Value
+		}
+	}
+	return ""
+}
+
+// ParseObjectKey parses a <namespace>/<name> reference.
+func ParseObjectKey(ref string) (client.ObjectKey, error) {
+	namespace, name, ok := strings.Cut(ref, "/")
+	if !ok || namespace == "" || name == "" {
+		return client.ObjectKey{}, kverrors.New("invalid reference, expected <namespace>/<name>", "reference", ref)
+	}
+	return client.ObjectKey{Namespace: namespace, Name: name}, nil
+}
@@ -0,0 +1,157 @@
+package authentication
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// CABundles maps a Target to the PEM encoded CA bundle that will be trusted by
+// the spoke clusters when sending data to that Target. Bundles registered for
+// AllTargets are merged in the bundle of every Target. The bundles are shipped
+// to the spokes in the trust bundle ConfigMap, see BuildTrustBundle.
+type CABundles map[Target]string
+
+// Add appends the given CA to the bundle of each of the targets. When no target
+// is provided the CA is added to the bundle shared by all targets. CAs already
+// present in a bundle are not added twice.
+func (b CABundles) Add(ca string, targets ...Target) {
+	ca = strings.TrimSpace(ca)
+	if ca == "" {
+		return
+	}
+
+	if len(targets) == 0 {
+		targets = []Target{AllTargets}
+	}
+
+	for _, target := range targets {
+		b[target] = mergeCA(b[target], ca)
+	}
+}
+
+// For returns the CA bundle for a Target, this is the result of merging the
+// bundle shared by all targets with the one specific to the Target.
+func (b CABundles) For(target Target) string {
+	bundle := b[AllTargets]
+	if target != AllTargets {
+		bundle = mergeCA(bundle, b[target])
+	}
+	return bundle
+}
+
+// Resolve returns the CA bundle of each of the targets that have at least one
+// CA configured, bundles shared by all targets are merged in each of them.
+func (b CABundles) Resolve(targets ...Target) CABundles {
+	resolved := make(CABundles, len(targets))
+	for _, target := range targets {
+		if bundle := b.For(target); bundle != "" {
+			resolved[target] = bundle
+		}
+	}
+	return resolved
+}
+
+// MTLSTargets returns the targets that requested mTLS authentication, these
+// are the targets that will make use of a CA bundle.
+func MTLSTargets(targetAuthType map[Target]AuthenticationType) []Target {
+	targets := []Target{}
+	for target, authType := range targetAuthType {
+		if authType == MTLS {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// TrustBundleKey returns the key used to store the CA bundle of a Target in
+// the trust bundle ConfigMap distributed to the spoke clusters. Slashes, used
+// in the names of OpenTelemetry exporters, are not valid in ConfigMap keys and
+// are replaced with underscores.
+func TrustBundleKey(target Target) string {
+	return fmt.Sprintf("%s.crt", strings.ReplaceAll(string(target), "/", "_"))
+}
+
+// BuildTrustBundle returns the data of the trust bundle ConfigMap, encoded in
+// JSON, holding the bundle of each Target under its TrustBundleKey. It's empty
+// when there are no bundles.
+func BuildTrustBundle(bundles CABundles) (string, error) {
+	if len(bundles) == 0 {
+		return "", nil
+	}
+
+	data := make(map[string]string, len(bundles))
+	for target, ca := range bundles {
+		data[TrustBundleKey(target)] = ca
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// AddCAFromConfigMap adds to the bundles the CA stored in the ConfigMap. The
+// targets of the CA are read from the targetsAnnotation as a comma separated
+// list, if the list is empty the CA will be used by all targets. The ConfigMap
+// key holding the CA can be overridden with the keyAnnotation, otherwise
+// DefaultConfigMapCAKey is used.
+func AddCAFromConfigMap(bundles CABundles, cm *corev1.ConfigMap, targetsAnnotation, keyAnnotation string) error {
+	key := caKey(cm.Annotations, keyAnnotation, DefaultConfigMapCAKey)
+	ca, ok := cm.Data[key]
+	if !ok {
+		return kverrors.New("missing ca bundle in configmap", "name", cm.Name, "namespace", cm.Namespace, "key", key)
+	}
+
+	bundles.Add(ca, caTargets(cm.Annotations[targetsAnnotation])...)
+	return nil
+}
+
+// AddCAFromSecret adds to the bundles the CA stored in the Secret. It follows
+// the same rules as AddCAFromConfigMap but defaults to DefaultSecretCAKey.
+func AddCAFromSecret(bundles CABundles, secret *corev1.Secret, targetsAnnotation, keyAnnotation string) error {
+	key := caKey(secret.Annotations, keyAnnotation, DefaultSecretCAKey)
+	ca, ok := secret.Data[key]
+	if !ok {
+		return kverrors.New("missing ca bundle in secret", "name", secret.Name, "namespace", secret.Namespace, "key", key)
+	}
+
+	bundles.Add(string(ca), caTargets(secret.Annotations[targetsAnnotation])...)
+	return nil
+}
+
+func caKey(annotations map[string]string, keyAnnotation, defaultKey string) string {
+	if key, ok := annotations[keyAnnotation]; ok && key != "" {
+		return key
+	}
+	return defaultKey
+}
+
+func caTargets(value string) []Target {
+	targets := []Target{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		targets = append(targets, Target(name))
+	}
+	return targets
+}
+
+func mergeCA(bundle, ca string) string {
+	switch {
+	case ca == "":
+		return bundle
+	case bundle == "":
+		return ca
+	case strings.Contains(bundle, ca):
+		return bundle
+	default:
+		return bundle + "\n" + ca
+	}
+}
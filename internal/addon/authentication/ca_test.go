@@ -0,0 +1,138 @@
+package authentication
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	caAnnotation    = "foo.mcoa.openshift.io/ca-targets"
+	caKeyAnnotation = "foo.mcoa.openshift.io/ca-key"
+)
+
+func Test_CABundles_For(t *testing.T) {
+	bundles := CABundles{}
+	bundles.Add("global-ca")
+	bundles.Add("target-1-ca", "target-1")
+	bundles.Add("target-1-ca", "target-1")
+	bundles.Add("shared-ca", "target-1", "target-2")
+
+	require.Equal(t, "global-ca\ntarget-1-ca\nshared-ca", bundles.For("target-1"))
+	require.Equal(t, "global-ca\nshared-ca", bundles.For("target-2"))
+	require.Equal(t, "global-ca", bundles.For("target-3"))
+}
+
+func Test_CABundles_Resolve(t *testing.T) {
+	bundles := CABundles{}
+	bundles.Add("global-ca")
+	bundles.Add("target-1-ca", "target-1")
+
+	targetAuthType := map[Target]AuthenticationType{
+		"target-1": MTLS,
+		"target-2": MTLS,
+		"target-3": Static,
+	}
+	resolved := bundles.Resolve(MTLSTargets(targetAuthType)...)
+	require.Equal(t, CABundles{
+		"target-1": "global-ca\ntarget-1-ca",
+		"target-2": "global-ca",
+	}, resolved)
+}
+
+func Test_AddCAFromConfigMap(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]string
+		data        map[string]string
+		expected    CABundles
+		wantErr     bool
+	}{
+		{
+			name:        "default key for all targets",
+			annotations: map[string]string{},
+			data: map[string]string{
+				"service-ca.crt": "ca",
+			},
+			expected: CABundles{AllTargets: "ca"},
+		},
+		{
+			name: "custom key for some targets",
+			annotations: map[string]string{
+				caAnnotation:    "target-1, target-2",
+				caKeyAnnotation: "bundle.pem",
+			},
+			data: map[string]string{
+				"bundle.pem": "ca",
+			},
+			expected: CABundles{"target-1": "ca", "target-2": "ca"},
+		},
+		{
+			name: "missing key",
+			annotations: map[string]string{
+				caAnnotation: "target-1",
+			},
+			data: map[string]string{
+				"ca.crt": "ca",
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "foo",
+					Namespace:   "bar",
+					Annotations: tc.annotations,
+				},
+				Data: tc.data,
+			}
+
+			bundles := CABundles{}
+			err := AddCAFromConfigMap(bundles, cm, caAnnotation, caKeyAnnotation)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, bundles)
+		})
+	}
+}
+
+func Test_AddCAFromSecret(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "bar",
+			Annotations: map[string]string{
+				caAnnotation: "target-1",
+			},
+		},
+		Data: map[string][]byte{
+			"ca.crt": []byte("ca"),
+		},
+	}
+
+	bundles := CABundles{}
+	err := AddCAFromSecret(bundles, secret, caAnnotation, caKeyAnnotation)
+	require.NoError(t, err)
+	require.Equal(t, CABundles{"target-1": "ca"}, bundles)
+}
+
+func Test_TrustBundleKey(t *testing.T) {
+	require.Equal(t, "loki.crt", TrustBundleKey("loki"))
+	require.Equal(t, "otlphttp_tempo.crt", TrustBundleKey("otlphttp/tempo"))
+}
+
+func Test_BuildTrustBundle(t *testing.T) {
+	trustBundle, err := BuildTrustBundle(CABundles{})
+	require.NoError(t, err)
+	require.Empty(t, trustBundle)
+
+	trustBundle, err = BuildTrustBundle(CABundles{"app-logs": "ca", "otlp/tempo": "tempo-ca"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"app-logs.crt":"ca","otlp_tempo.crt":"tempo-ca"}`, trustBundle)
+}
@@ -0,0 +1,130 @@
+package authentication
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AuthConfigConflict reports a target that is configured with different
+// authentication types by ConfigMaps of the same precedence. The type of the
+// last ConfigMap, ordered by namespace and name, is used.
+type AuthConfigConflict struct {
+	Target     Target
+	ConfigMaps []string
+	Types      []AuthenticationType
+}
+
+func (c AuthConfigConflict) String() string {
+	return fmt.Sprintf("target %q is configured with %v by configmaps %v", c.Target, c.Types, c.ConfigMaps)
+}
+
+// MergeAuthConfigMaps builds the authentication map of all the ConfigMaps
+// configuring authentication. ConfigMaps in the namespace of the cluster
+// override the defaults set by ConfigMaps in any other namespace. ConfigMaps
+// of the same precedence are merged ordered by namespace and name, targets
+// that they set to different types are returned as conflicts.
+func MergeAuthConfigMaps(clusterName string, cms []corev1.ConfigMap) (map[Target]AuthenticationType, []AuthConfigConflict) {
+	var defaults, overrides []corev1.ConfigMap
+	for _, cm := range cms {
+		if cm.Namespace == clusterName {
+			overrides = append(overrides, cm)
+			continue
+		}
+		defaults = append(defaults, cm)
+	}
+
+	result := map[Target]AuthenticationType{}
+	var conflicts []AuthConfigConflict
+	for _, group := range [][]corev1.ConfigMap{defaults, overrides} {
+		merged, groupConflicts := mergeAuthenticationMaps(group)
+		for target, authType := range merged {
+			result[target] = authType
+		}
+		conflicts = append(conflicts, groupConflicts...)
+	}
+
+	return result, conflicts
+}
+
+func mergeAuthenticationMaps(configMaps []corev1.ConfigMap) (map[Target]AuthenticationType, []AuthConfigConflict) {
+	// Sort a copy to leave the order of the caller's slice untouched
+	cms := make([]corev1.ConfigMap, len(configMaps))
+	copy(cms, configMaps)
+	sort.Slice(cms, func(i, j int) bool {
+		if cms[i].Namespace != cms[j].Namespace {
+			return cms[i].Namespace < cms[j].Namespace
+		}
+		return cms[i].Name < cms[j].Name
+	})
+
+	result := map[Target]AuthenticationType{}
+	sources := map[Target][]string{}
+	types := map[Target][]AuthenticationType{}
+	for _, cm := range cms {
+		for target, authType := range BuildAuthenticationMap(cm.Data) {
+			result[target] = authType
+			sources[target] = append(sources[target], fmt.Sprintf("%s/%s", cm.Namespace, cm.Name))
+			types[target] = append(types[target], authType)
+		}
+	}
+
+	var conflicts []AuthConfigConflict
+	for target, targetTypes := range types {
+		for _, authType := range targetTypes[1:] {
+			if authType != targetTypes[0] {
+				conflicts = append(conflicts, AuthConfigConflict{
+					Target:     target,
+					ConfigMaps: sources[target],
+					Types:      targetTypes,
+				})
+				break
+			}
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Target < conflicts[j].Target
+	})
+
+	return result, conflicts
+}
+
+// ReportAuthConfigConflicts sets the condition of the signal on the
+// ManagedClusterAddOn reporting the conflicts between its auth ConfigMaps. The
+// condition is only set once a conflict was found.
+func ReportAuthConfigConflicts(ctx context.Context, k8s client.Client, mcAddon *addonapiv1alpha1.ManagedClusterAddOn, signal addon.Signal, conflicts []AuthConfigConflict) error {
+	condition := buildAuthConfigCondition(signal.String(), conflicts)
+	if len(conflicts) == 0 && meta.FindStatusCondition(mcAddon.Status.Conditions, condition.Type) == nil {
+		return nil
+	}
+	return addon.UpdateCondition(ctx, k8s, mcAddon, condition)
+}
+
+func buildAuthConfigCondition(signal string, conflicts []AuthConfigConflict) metav1.Condition {
+	condition := metav1.Condition{
+		Type:    signalConditionType(signal, AuthConfigConflictingCondition),
+		Status:  metav1.ConditionFalse,
+		Reason:  ReasonAuthConfigConsistent,
+		Message: "Auth ConfigMaps don't conflict",
+	}
+	if len(conflicts) == 0 {
+		return condition
+	}
+
+	messages := make([]string, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		messages = append(messages, conflict.String())
+	}
+	condition.Status = metav1.ConditionTrue
+	condition.Reason = ReasonAuthConfigConflict
+	condition.Message = strings.Join(messages, "; ")
+	return condition
+}
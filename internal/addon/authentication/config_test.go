@@ -0,0 +1,96 @@
+package authentication
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_MergeAuthConfigMaps(t *testing.T) {
+	authCM := func(namespace, name string, data map[string]string) corev1.ConfigMap {
+		return corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       data,
+		}
+	}
+
+	for _, tc := range []struct {
+		name              string
+		cms               []corev1.ConfigMap
+		expected          map[Target]AuthenticationType
+		expectedConflicts []AuthConfigConflict
+	}{
+		{
+			name:     "no configmaps",
+			expected: map[Target]AuthenticationType{},
+		},
+		{
+			name: "cluster namespace overrides defaults",
+			cms: []corev1.ConfigMap{
+				authCM("cluster-1", "overrides", map[string]string{"loki": "mTLS"}),
+				authCM("open-cluster-management", "defaults", map[string]string{"loki": "StaticAuthentication", "cloudwatch": "StaticAuthentication"}),
+			},
+			expected: map[Target]AuthenticationType{
+				"loki":       MTLS,
+				"cloudwatch": Static,
+			},
+		},
+		{
+			name: "same precedence conflict is reported",
+			cms: []corev1.ConfigMap{
+				authCM("open-cluster-management", "defaults-b", map[string]string{"loki": "mTLS", "splunk": "StaticAuthentication"}),
+				authCM("open-cluster-management", "defaults-a", map[string]string{"loki": "StaticAuthentication", "splunk": "StaticAuthentication"}),
+			},
+			expected: map[Target]AuthenticationType{
+				"loki":   MTLS,
+				"splunk": Static,
+			},
+			expectedConflicts: []AuthConfigConflict{
+				{
+					Target:     "loki",
+					ConfigMaps: []string{"open-cluster-management/defaults-a", "open-cluster-management/defaults-b"},
+					Types:      []AuthenticationType{Static, MTLS},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			merged, conflicts := MergeAuthConfigMaps("cluster-1", tc.cms)
+			require.Equal(t, tc.expected, merged)
+			require.Equal(t, tc.expectedConflicts, conflicts)
+		})
+	}
+}
+
+func Test_MergeAuthenticationMaps_KeepsOrder(t *testing.T) {
+	cms := []corev1.ConfigMap{
+		{ObjectMeta: metav1.ObjectMeta{Name: "defaults-b", Namespace: "open-cluster-management"}, Data: map[string]string{"loki": "mTLS"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "defaults-a", Namespace: "open-cluster-management"}, Data: map[string]string{"loki": "StaticAuthentication"}},
+	}
+
+	merged, _ := mergeAuthenticationMaps(cms)
+	require.Equal(t, map[Target]AuthenticationType{"loki": MTLS}, merged)
+	require.Equal(t, "defaults-b", cms[0].Name)
+	require.Equal(t, "defaults-a", cms[1].Name)
+}
+
+func Test_BuildAuthConfigCondition(t *testing.T) {
+	condition := buildAuthConfigCondition("tracing", nil)
+	require.Equal(t, "TracingAuthConfigConflicting", condition.Type)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Equal(t, ReasonAuthConfigConsistent, condition.Reason)
+
+	condition = buildAuthConfigCondition("logging", []AuthConfigConflict{
+		{
+			Target:     "loki",
+			ConfigMaps: []string{"a/auth", "b/auth"},
+			Types:      []AuthenticationType{Static, MTLS},
+		},
+	})
+	require.Equal(t, "LoggingAuthConfigConflicting", condition.Type)
+	require.Equal(t, metav1.ConditionTrue, condition.Status)
+	require.Equal(t, ReasonAuthConfigConflict, condition.Reason)
+	require.Equal(t, `target "loki" is configured with [StaticAuthentication mTLS] by configmaps [a/auth b/auth]`, condition.Message)
+}
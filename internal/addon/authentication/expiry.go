@@ -0,0 +1,160 @@
+package authentication
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/rhobs/multicluster-observability-addon/internal/manifests"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CertificateStatus summarizes the state of the Certificate issued for a
+// Target that uses mTLS authentication
+type CertificateStatus struct {
+	Target                 Target
+	Ready                  bool
+	NotAfter               *time.Time
+	LastRenewal            *time.Time
+	FailedIssuanceAttempts int
+}
+
+// Expiring returns true if the certificate expires before the warning
+// threshold
+func (cs CertificateStatus) Expiring(now time.Time) bool {
+	if cs.NotAfter == nil {
+		return false
+	}
+	return cs.NotAfter.Sub(now) < certificateExpiryWarningThreshold
+}
+
+// Failed returns true if the last issuance of the certificate failed
+func (cs CertificateStatus) Failed() bool {
+	return cs.FailedIssuanceAttempts > 0
+}
+
+// CertificatesStatus fetches the Cert-Manager Certificates created for the
+// Target's that requested mTLS authentication and returns their status.
+// Certificates that do not exist yet are skipped.
+func (sp *secretsProvider) CertificatesStatus(ctx context.Context, targetAuthType map[Target]AuthenticationType, targetsSecret map[Target]SecretKey) ([]CertificateStatus, error) {
+	statuses := []CertificateStatus{}
+	for _, target := range MTLSTargets(targetAuthType) {
+		cert := &certmanagerv1.Certificate{}
+		key := manifests.CertificateKey(client.ObjectKey(targetsSecret[target]))
+		if err := sp.k8s.Get(ctx, key, cert, &client.GetOptions{}); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return statuses, err
+		}
+		statuses = append(statuses, buildCertificateStatus(target, cert))
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Target < statuses[j].Target
+	})
+	return statuses, nil
+}
+
+// ReportCertificates exports the certificates status as metrics and sets a
+// condition on the ManagedClusterAddOn warning about certificates that are
+// close to expire or that failed to be renewed.
+func (sp *secretsProvider) ReportCertificates(ctx context.Context, mcAddon *addonapiv1alpha1.ManagedClusterAddOn, statuses []CertificateStatus) error {
+	recordCertificatesMetrics(sp.clusterName, sp.signal.String(), statuses)
+
+	condition := buildCertificatesCondition(sp.signal.String(), statuses, time.Now())
+	if len(statuses) == 0 && meta.FindStatusCondition(mcAddon.Status.Conditions, condition.Type) == nil {
+		return nil
+	}
+	if err := addon.UpdateCondition(ctx, sp.k8s, mcAddon, condition); err != nil {
+		return err
+	}
+	klog.Infof("certificates condition set to %s for signal %s", condition.Reason, sp.signal)
+	return nil
+}
+
+func buildCertificateStatus(target Target, cert *certmanagerv1.Certificate) CertificateStatus {
+	status := CertificateStatus{
+		Target: target,
+	}
+
+	for _, condition := range cert.Status.Conditions {
+		if condition.Type == certmanagerv1.CertificateConditionReady {
+			status.Ready = condition.Status == cmmetav1.ConditionTrue
+		}
+	}
+
+	// The Ready condition stays True across renewals, the validity start of
+	// the issued certificate is updated instead
+	if cert.Status.NotBefore != nil {
+		lastRenewal := cert.Status.NotBefore.Time
+		status.LastRenewal = &lastRenewal
+	}
+
+	if cert.Status.NotAfter != nil {
+		notAfter := cert.Status.NotAfter.Time
+		status.NotAfter = &notAfter
+	}
+
+	if cert.Status.FailedIssuanceAttempts != nil {
+		status.FailedIssuanceAttempts = *cert.Status.FailedIssuanceAttempts
+	}
+
+	return status
+}
+
+func buildCertificatesCondition(signal string, statuses []CertificateStatus, now time.Time) metav1.Condition {
+	var expiring, failed []string
+	for _, status := range statuses {
+		if status.Failed() {
+			failed = append(failed, string(status.Target))
+		}
+		if status.Expiring(now) {
+			expiring = append(expiring, string(status.Target))
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:    signalConditionType(signal, CertificatesDegradedCondition),
+		Status:  metav1.ConditionFalse,
+		Reason:  ReasonCertificatesValid,
+		Message: "All certificates are valid",
+	}
+
+	messages := []string{}
+	if len(failed) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ReasonCertificateRenewalFailed
+		messages = append(messages, fmt.Sprintf("failed to renew certificates for targets: %s", strings.Join(failed, ", ")))
+	}
+	if len(expiring) > 0 {
+		condition.Status = metav1.ConditionTrue
+		if condition.Reason == ReasonCertificatesValid {
+			condition.Reason = ReasonCertificateExpiring
+		}
+		messages = append(messages, fmt.Sprintf("certificates for targets expire in less than %s: %s", certificateExpiryWarningThreshold, strings.Join(expiring, ", ")))
+	}
+	if len(messages) > 0 {
+		condition.Message = strings.Join(messages, "; ")
+	}
+
+	return condition
+}
+
+// signalConditionType prefixes the condition type with the signal it is set for
+func signalConditionType(signal, conditionType string) string {
+	if signal == "" {
+		return conditionType
+	}
+	return strings.ToUpper(signal[:1]) + signal[1:] + conditionType
+}
@@ -0,0 +1,178 @@
+package authentication
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"open-cluster-management.io/addon-framework/pkg/addonmanager/addontesting"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_CertificatesStatus(t *testing.T) {
+	notAfter := metav1.NewTime(time.Now().Add(30 * 24 * time.Hour))
+	renewal := metav1.NewTime(time.Now().Add(-24 * time.Hour))
+	cert := &certmanagerv1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "logging-target-1-auth-cert",
+			Namespace: "cluster-1",
+		},
+		Status: certmanagerv1.CertificateStatus{
+			NotBefore:              &renewal,
+			NotAfter:               &notAfter,
+			FailedIssuanceAttempts: ptr.To(2),
+			Conditions: []certmanagerv1.CertificateCondition{
+				{
+					Type:   certmanagerv1.CertificateConditionReady,
+					Status: cmmetav1.ConditionTrue,
+				},
+			},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, certmanagerv1.AddToScheme(scheme))
+	fakeKubeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(cert).
+		Build()
+
+	sp, err := NewSecretsProvider(fakeKubeClient, "cluster-1", "logging", &Config{})
+	require.NoError(t, err)
+
+	targetAuth := map[Target]AuthenticationType{
+		"target-1": MTLS,
+		"target-2": MTLS,
+		"target-3": Static,
+	}
+	targetKeys := map[Target]SecretKey{
+		"target-1": {Name: "logging-target-1-auth", Namespace: "cluster-1"},
+		"target-2": {Name: "logging-target-2-auth", Namespace: "cluster-1"},
+		"target-3": {Name: "logging-target-3-auth", Namespace: "cluster-1"},
+	}
+
+	statuses, err := sp.CertificatesStatus(context.TODO(), targetAuth, targetKeys)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	require.Equal(t, Target("target-1"), statuses[0].Target)
+	require.True(t, statuses[0].Ready)
+	require.True(t, statuses[0].Failed())
+	require.False(t, statuses[0].Expiring(time.Now()))
+	require.Equal(t, notAfter.Unix(), statuses[0].NotAfter.Unix())
+	require.Equal(t, renewal.Unix(), statuses[0].LastRenewal.Unix())
+}
+
+func Test_BuildCertificateStatus_RenewedWhileReady(t *testing.T) {
+	ready := metav1.NewTime(time.Now().Add(-60 * 24 * time.Hour))
+	issued := metav1.NewTime(time.Now().Add(-30 * 24 * time.Hour))
+	renewed := metav1.NewTime(time.Now().Add(-time.Hour))
+	cert := &certmanagerv1.Certificate{
+		Status: certmanagerv1.CertificateStatus{
+			NotBefore: &issued,
+			Conditions: []certmanagerv1.CertificateCondition{
+				{
+					Type:               certmanagerv1.CertificateConditionReady,
+					Status:             cmmetav1.ConditionTrue,
+					LastTransitionTime: &ready,
+				},
+			},
+		},
+	}
+
+	status := buildCertificateStatus("target-1", cert)
+	require.True(t, status.Ready)
+	require.Equal(t, issued.Unix(), status.LastRenewal.Unix())
+
+	// cert-manager renews the certificate without the Ready condition
+	// transitioning
+	cert.Status.NotBefore = &renewed
+	status = buildCertificateStatus("target-1", cert)
+	require.True(t, status.Ready)
+	require.Equal(t, renewed.Unix(), status.LastRenewal.Unix())
+}
+
+func Test_BuildCertificatesCondition(t *testing.T) {
+	now := time.Now()
+	soon := now.Add(24 * time.Hour)
+	later := now.Add(60 * 24 * time.Hour)
+
+	for _, tc := range []struct {
+		name     string
+		statuses []CertificateStatus
+		status   metav1.ConditionStatus
+		reason   string
+	}{
+		{
+			name: "valid",
+			statuses: []CertificateStatus{
+				{Target: "target-1", NotAfter: &later},
+			},
+			status: metav1.ConditionFalse,
+			reason: ReasonCertificatesValid,
+		},
+		{
+			name: "expiring",
+			statuses: []CertificateStatus{
+				{Target: "target-1", NotAfter: &later},
+				{Target: "target-2", NotAfter: &soon},
+			},
+			status: metav1.ConditionTrue,
+			reason: ReasonCertificateExpiring,
+		},
+		{
+			name: "failed",
+			statuses: []CertificateStatus{
+				{Target: "target-1", NotAfter: &soon},
+				{Target: "target-2", NotAfter: &later, FailedIssuanceAttempts: 1},
+			},
+			status: metav1.ConditionTrue,
+			reason: ReasonCertificateRenewalFailed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			condition := buildCertificatesCondition("logging", tc.statuses, now)
+			require.Equal(t, "LoggingCertificatesDegraded", condition.Type)
+			require.Equal(t, tc.status, condition.Status)
+			require.Equal(t, tc.reason, condition.Reason)
+		})
+	}
+}
+
+func Test_ReportCertificates(t *testing.T) {
+	mcAddon := addontesting.NewAddon("test", "cluster-1")
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, addonapiv1alpha1.AddToScheme(scheme))
+	fakeKubeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(mcAddon).
+		WithStatusSubresource(mcAddon).
+		Build()
+
+	sp, err := NewSecretsProvider(fakeKubeClient, "cluster-1", "tracing", &Config{})
+	require.NoError(t, err)
+
+	soon := time.Now().Add(time.Hour)
+	statuses := []CertificateStatus{
+		{Target: "otlphttp", NotAfter: &soon},
+	}
+	err = sp.ReportCertificates(context.TODO(), mcAddon, statuses)
+	require.NoError(t, err)
+
+	got := &addonapiv1alpha1.ManagedClusterAddOn{}
+	err = fakeKubeClient.Get(context.TODO(), client.ObjectKeyFromObject(mcAddon), got)
+	require.NoError(t, err)
+	condition := meta.FindStatusCondition(got.Status.Conditions, "TracingCertificatesDegraded")
+	require.NotNil(t, condition)
+	require.Equal(t, metav1.ConditionTrue, condition.Status)
+	require.Equal(t, ReasonCertificateExpiring, condition.Reason)
+}
@@ -0,0 +1,129 @@
+package authentication
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var (
+	certificateLabels = []string{"cluster", "signal", "target"}
+
+	certificateExpiration = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      metricsNamespace,
+			Name:           "certificate_expiration_timestamp_seconds",
+			Help:           "Timestamp at which the certificate issued for a target expires.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		certificateLabels,
+	)
+
+	certificateLastRenewal = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      metricsNamespace,
+			Name:           "certificate_last_renewal_timestamp_seconds",
+			Help:           "Timestamp of the last time the certificate issued for a target was renewed.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		certificateLabels,
+	)
+
+	certificateFailedIssuanceAttempts = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      metricsNamespace,
+			Name:           "certificate_failed_issuance_attempts",
+			Help:           "Number of consecutive failed attempts to issue the certificate for a target.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		certificateLabels,
+	)
+)
+
+func init() {
+	legacyregistry.MustRegister(
+		certificateExpiration,
+		certificateLastRenewal,
+		certificateFailedIssuanceAttempts,
+	)
+}
+
+// certificateSeries identifies the series of the certificates issued for a
+// cluster and signal
+type certificateSeries struct {
+	clusterName string
+	signal      string
+}
+
+// recordedTargets tracks the targets with series so that the series of removed
+// targets can be deleted.
+var (
+	recordedTargetsMu sync.Mutex
+	recordedTargets   = map[certificateSeries]map[Target]struct{}{}
+)
+
+// recordCertificatesMetrics sets the series of the certificates of a cluster
+// for the signal and deletes the series of targets that don't have a
+// certificate anymore.
+func recordCertificatesMetrics(clusterName, signal string, statuses []CertificateStatus) {
+	recordedTargetsMu.Lock()
+	defer recordedTargetsMu.Unlock()
+
+	series := certificateSeries{clusterName: clusterName, signal: signal}
+	targets := make(map[Target]struct{}, len(statuses))
+	for _, status := range statuses {
+		recordCertificateMetrics(series, status)
+		targets[status.Target] = struct{}{}
+	}
+
+	for target := range recordedTargets[series] {
+		if _, ok := targets[target]; !ok {
+			deleteCertificateMetrics(series, target)
+		}
+	}
+
+	if len(targets) == 0 {
+		delete(recordedTargets, series)
+		return
+	}
+	recordedTargets[series] = targets
+}
+
+// DeleteCertificatesMetrics deletes the series of all the certificates issued
+// for a cluster.
+func DeleteCertificatesMetrics(clusterName string) {
+	recordedTargetsMu.Lock()
+	defer recordedTargetsMu.Unlock()
+
+	for series, targets := range recordedTargets {
+		if series.clusterName != clusterName {
+			continue
+		}
+		for target := range targets {
+			deleteCertificateMetrics(series, target)
+		}
+		delete(recordedTargets, series)
+	}
+}
+
+func recordCertificateMetrics(series certificateSeries, status CertificateStatus) {
+	labels := []string{series.clusterName, series.signal, string(status.Target)}
+
+	if status.NotAfter != nil {
+		certificateExpiration.WithLabelValues(labels...).Set(float64(status.NotAfter.Unix()))
+	} else {
+		certificateExpiration.DeleteLabelValues(labels...)
+	}
+	if status.LastRenewal != nil {
+		certificateLastRenewal.WithLabelValues(labels...).Set(float64(status.LastRenewal.Unix()))
+	}
+	certificateFailedIssuanceAttempts.WithLabelValues(labels...).Set(float64(status.FailedIssuanceAttempts))
+}
+
+func deleteCertificateMetrics(series certificateSeries, target Target) {
+	labels := []string{series.clusterName, series.signal, string(target)}
+	certificateExpiration.DeleteLabelValues(labels...)
+	certificateLastRenewal.DeleteLabelValues(labels...)
+	certificateFailedIssuanceAttempts.DeleteLabelValues(labels...)
+}
@@ -0,0 +1,52 @@
+package authentication
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// expirationSeries returns the value of the expiration series of each target of
+// the cluster.
+func expirationSeries(t *testing.T, clusterName string) map[string]float64 {
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	series := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != "mcoa_certificate_expiration_timestamp_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["cluster"] == clusterName {
+				series[labels["target"]] = metric.GetGauge().GetValue()
+			}
+		}
+	}
+	return series
+}
+
+func Test_RecordCertificatesMetrics(t *testing.T) {
+	notAfter := time.Unix(1700000000, 0)
+
+	recordCertificatesMetrics("metrics-cluster", "logging", []CertificateStatus{
+		{Target: "loki", NotAfter: &notAfter},
+		{Target: "http", NotAfter: &notAfter},
+	})
+	require.Equal(t, map[string]float64{"loki": 1700000000, "http": 1700000000}, expirationSeries(t, "metrics-cluster"))
+
+	// Targets without a certificate anymore lose their series
+	recordCertificatesMetrics("metrics-cluster", "logging", []CertificateStatus{
+		{Target: "loki", NotAfter: &notAfter},
+	})
+	require.Equal(t, map[string]float64{"loki": 1700000000}, expirationSeries(t, "metrics-cluster"))
+
+	DeleteCertificatesMetrics("metrics-cluster")
+	require.Empty(t, expirationSeries(t, "metrics-cluster"))
+}
@@ -86,6 +86,13 @@ func (sp *secretsProvider) GenerateSecrets(ctx context.Context, targetAuthType m
 		if err != nil {
 			return nil, err
 		}
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[addon.SignalLabelKey] = sp.signal.String()
+		labels[ManagedByLabelKey] = addon.Name
+		obj.SetLabels(labels)
 		objects = append(objects, obj)
 		secretKeys[targetName] = SecretKey(secretKey)
 	}
@@ -109,11 +116,6 @@ func (sp *secretsProvider) GenerateSecrets(ctx context.Context, targetAuthType m
 		}
 	}
 
-	err := sp.injectCA(ctx, targetAuthType, secretKeys)
-	if err != nil {
-		return nil, err
-	}
-
 	return secretKeys, nil
 }
 
@@ -137,48 +139,6 @@ func (sp *secretsProvider) FetchSecrets(ctx context.Context, targetsSecret map[T
 	return secrets, nil
 }
 
-// injectCA will for Target's that requested mTLS authentication inject in the secret
-// an "ca-bundle.crt" key containing the CA configured in the secretsProvider Config
-func (sp *secretsProvider) injectCA(ctx context.Context, targetAuthType map[Target]AuthenticationType, targetsSecret map[Target]SecretKey) error {
-	if sp.MTLSConfig.CAToInject == "" {
-		return nil
-	}
-
-	objects := []client.Object{}
-	for target, authType := range targetAuthType {
-		switch authType {
-		case MTLS:
-			secret := &corev1.Secret{}
-			key := client.ObjectKey(targetsSecret[target])
-			if err := sp.k8s.Get(ctx, key, secret, &client.GetOptions{}); err != nil {
-				return err
-			}
-			manifests.InjectCA(secret, sp.MTLSConfig.CAToInject)
-			objects = append(objects, secret)
-		}
-	}
-
-	for _, obj := range objects {
-		desired := obj.DeepCopyObject().(client.Object)
-		mutateFn := manifests.MutateFuncFor(obj, desired, nil)
-
-		op, err := ctrl.CreateOrUpdate(ctx, sp.k8s, obj, mutateFn)
-		if err != nil {
-			klog.Error(err, "failed to configure resource")
-			continue
-		}
-
-		msg := fmt.Sprintf("Resource has been %s", op)
-		switch op {
-		case ctrlutil.OperationResultNone:
-			klog.Info(msg)
-		default:
-			klog.Info(msg)
-		}
-	}
-	return nil
-}
-
 func BuildAuthenticationMap(inputMap map[string]string) map[Target]AuthenticationType {
 	result := make(map[Target]AuthenticationType, len(inputMap))
 
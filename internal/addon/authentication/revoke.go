@@ -0,0 +1,297 @@
+package authentication
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// IncompleteRevocationError is returned when the credentials of a cluster were
+// revoked but some of them need an out-of-band action: the certificates whose
+// serial couldn't be read were deleted without being added to the deny-list,
+// and the shared static secrets can't be rotated by the addon.
+type IncompleteRevocationError struct {
+	Cluster      string
+	Certificates []client.ObjectKey
+	Secrets      []client.ObjectKey
+}
+
+func (e *IncompleteRevocationError) Error() string {
+	msg := fmt.Sprintf("credentials of cluster %s revoked", e.Cluster)
+	if len(e.Certificates) > 0 {
+		msg += fmt.Sprintf(", the certificates of the secrets %s couldn't be added to the deny-list", joinObjectKeys(e.Certificates))
+	}
+	if len(e.Secrets) > 0 {
+		msg += fmt.Sprintf(", the shared secrets %s can't be rotated by the addon and must be rotated out-of-band", joinObjectKeys(e.Secrets))
+	}
+	return msg
+}
+
+func joinObjectKeys(keys []client.ObjectKey) string {
+	list := make([]string, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, key.String())
+	}
+	return strings.Join(list, ", ")
+}
+
+// RequestRevocation annotates the ManagedCluster with
+// AnnotationRevokeCredentials and revokes its credentials, so that no new
+// credentials are issued for it until the annotation is removed. The
+// credentials of a cluster that no longer exists are revoked directly.
+func RequestRevocation(ctx context.Context, k8s client.Client, clusterName string, sharedSecrets []client.ObjectKey) error {
+	cluster := &clusterv1.ManagedCluster{}
+	if err := k8s.Get(ctx, client.ObjectKey{Name: clusterName}, cluster, &client.GetOptions{}); err != nil {
+		if errors.IsNotFound(err) {
+			return RevokeCredentials(ctx, k8s, clusterName, sharedSecrets)
+		}
+		return err
+	}
+
+	if _, ok := cluster.Annotations[AnnotationRevokeCredentials]; !ok {
+		updated := cluster.DeepCopy()
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[AnnotationRevokeCredentials] = "true"
+		if err := k8s.Patch(ctx, updated, client.MergeFrom(cluster)); err != nil {
+			return err
+		}
+		cluster = updated
+	}
+
+	_, err := ReconcileRevocation(ctx, k8s, cluster, sharedSecrets)
+	return err
+}
+
+// RevokeCredentials revokes all the credentials issued by the addon for a
+// cluster. The serials of the certificates issued for mTLS targets are added to
+// the deny-list ConfigMap so that receiving gateways can reject them, after
+// which all Certificates and Secrets are deleted. Shared static secrets, which
+// can't be rotated by the addon, are annotated to signal that they need to be
+// rotated and an IncompleteRevocationError listing them is returned, along
+// with the certificates that couldn't be added to the deny-list.
+func RevokeCredentials(ctx context.Context, k8s client.Client, clusterName string, sharedSecrets []client.ObjectKey) error {
+	selector := client.MatchingLabels{ManagedByLabelKey: addon.Name}
+
+	certs := &certmanagerv1.CertificateList{}
+	if err := k8s.List(ctx, certs, client.InNamespace(clusterName), selector); err != nil {
+		return err
+	}
+
+	revoked := map[string]string{}
+	var unlisted []client.ObjectKey
+	for _, cert := range certs.Items {
+		secret := &corev1.Secret{}
+		key := client.ObjectKey{Name: cert.Spec.SecretName, Namespace: cert.Namespace}
+		if err := k8s.Get(ctx, key, secret, &client.GetOptions{}); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+
+		serial, err := certificateSerial(secret)
+		if err != nil {
+			klog.Error(err, "failed to read the serial of the certificate, it won't be added to the deny-list")
+			unlisted = append(unlisted, key)
+			continue
+		}
+		revoked[serial] = fmt.Sprintf("%s/%s", clusterName, secret.Name)
+	}
+
+	if err := addToDenyList(ctx, k8s, revoked); err != nil {
+		return err
+	}
+
+	for i := range certs.Items {
+		if err := deleteIgnoreNotFound(ctx, k8s, &certs.Items[i]); err != nil {
+			return err
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      certs.Items[i].Spec.SecretName,
+				Namespace: certs.Items[i].Namespace,
+			},
+		}
+		if err := deleteIgnoreNotFound(ctx, k8s, secret); err != nil {
+			return err
+		}
+	}
+
+	secrets := &corev1.SecretList{}
+	if err := k8s.List(ctx, secrets, client.InNamespace(clusterName), selector); err != nil {
+		return err
+	}
+	for i := range secrets.Items {
+		if err := deleteIgnoreNotFound(ctx, k8s, &secrets.Items[i]); err != nil {
+			return err
+		}
+	}
+
+	rotate := []client.ObjectKey{}
+	for _, key := range sharedSecrets {
+		found, err := requestRotation(ctx, k8s, key, clusterName)
+		if err != nil {
+			return err
+		}
+		if found {
+			rotate = append(rotate, key)
+		}
+	}
+
+	DeleteCertificatesMetrics(clusterName)
+
+	klog.Infof("revoked %d certificates and %d secrets for cluster %s", len(certs.Items), len(secrets.Items), clusterName)
+	if len(unlisted) > 0 || len(rotate) > 0 {
+		return &IncompleteRevocationError{Cluster: clusterName, Certificates: unlisted, Secrets: rotate}
+	}
+	return nil
+}
+
+// ReconcileRevocation revokes the credentials of a cluster annotated with
+// AnnotationRevokeCredentials once and records it with
+// AnnotationCredentialsRevoked. It returns true while no credentials must be
+// issued for the cluster. An IncompleteRevocationError is returned as long as
+// the shared secrets used by the cluster weren't rotated. The record is
+// removed with the annotation so that the credentials of the cluster can be
+// revoked again later.
+func ReconcileRevocation(ctx context.Context, k8s client.Client, cluster *clusterv1.ManagedCluster, sharedSecrets []client.ObjectKey) (bool, error) {
+	_, revoke := cluster.Annotations[AnnotationRevokeCredentials]
+	_, revoked := cluster.Annotations[AnnotationCredentialsRevoked]
+
+	switch {
+	case revoke && revoked:
+		return true, pendingRotations(ctx, k8s, cluster.Name, sharedSecrets)
+	case revoke:
+		err := RevokeCredentials(ctx, k8s, cluster.Name, sharedSecrets)
+		if _, ok := err.(*IncompleteRevocationError); err != nil && !ok {
+			return true, err
+		}
+		if patchErr := setCredentialsRevoked(ctx, k8s, cluster, true); patchErr != nil {
+			return true, patchErr
+		}
+		return true, err
+	case revoked:
+		return false, setCredentialsRevoked(ctx, k8s, cluster, false)
+	}
+	return false, nil
+}
+
+// pendingRotations returns an IncompleteRevocationError listing the shared
+// secrets still annotated as requiring a rotation for the cluster.
+func pendingRotations(ctx context.Context, k8s client.Client, clusterName string, sharedSecrets []client.ObjectKey) error {
+	rotate := []client.ObjectKey{}
+	for _, key := range sharedSecrets {
+		secret := &corev1.Secret{}
+		if err := k8s.Get(ctx, key, secret, &client.GetOptions{}); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if secret.Annotations[AnnotationRotationRequired] == clusterName {
+			rotate = append(rotate, key)
+		}
+	}
+
+	if len(rotate) > 0 {
+		return &IncompleteRevocationError{Cluster: clusterName, Secrets: rotate}
+	}
+	return nil
+}
+
+func setCredentialsRevoked(ctx context.Context, k8s client.Client, cluster *clusterv1.ManagedCluster, revoked bool) error {
+	updated := cluster.DeepCopy()
+	if revoked {
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[AnnotationCredentialsRevoked] = time.Now().UTC().Format(time.RFC3339)
+	} else {
+		delete(updated.Annotations, AnnotationCredentialsRevoked)
+	}
+	return k8s.Patch(ctx, updated, client.MergeFrom(cluster))
+}
+
+func certificateSerial(secret *corev1.Secret) (string, error) {
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if block == nil {
+		return "", kverrors.New("failed to decode certificate", "name", secret.Name, "namespace", secret.Namespace)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", kverrors.Wrap(err, "failed to parse certificate", "name", secret.Name, "namespace", secret.Namespace)
+	}
+	return cert.SerialNumber.Text(16), nil
+}
+
+// addToDenyList adds the serials to the deny-list ConfigMap. Each key of the
+// ConfigMap is a serial in hexadecimal and the value identifies the cluster and
+// the secret that held the certificate.
+func addToDenyList(ctx context.Context, k8s client.Client, revoked map[string]string) error {
+	if len(revoked) == 0 {
+		return nil
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DenyListName,
+			Namespace: addon.InstallNamespace,
+		},
+	}
+	_, err := ctrl.CreateOrUpdate(ctx, k8s, cm, func() error {
+		if cm.Data == nil {
+			cm.Data = make(map[string]string, len(revoked))
+		}
+		for serial, owner := range revoked {
+			cm.Data[serial] = owner
+		}
+		return nil
+	})
+	return err
+}
+
+// requestRotation annotates the shared secret as requiring a rotation and
+// returns whether it exists.
+func requestRotation(ctx context.Context, k8s client.Client, key client.ObjectKey, clusterName string) (bool, error) {
+	secret := &corev1.Secret{}
+	if err := k8s.Get(ctx, key, secret, &client.GetOptions{}); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	updated := secret.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Annotations[AnnotationRotationRequired] = clusterName
+	if err := k8s.Patch(ctx, updated, client.MergeFrom(secret)); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+func deleteIgnoreNotFound(ctx context.Context, k8s client.Client, obj client.Object) error {
+	if err := k8s.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
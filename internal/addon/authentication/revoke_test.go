@@ -0,0 +1,244 @@
+package authentication
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func generateCertificate(t *testing.T, serial int64) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "cluster-1"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func Test_RevokeCredentials(t *testing.T) {
+	labels := map[string]string{
+		ManagedByLabelKey: "multicluster-observability-addon",
+	}
+
+	cert := &certmanagerv1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "tracing-otlp-auth-cert",
+			Namespace: "cluster-1",
+			Labels:    labels,
+		},
+		Spec: certmanagerv1.CertificateSpec{
+			SecretName: "tracing-otlp-auth",
+		},
+	}
+	certSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "tracing-otlp-auth",
+			Namespace: "cluster-1",
+		},
+		Data: map[string][]byte{
+			"tls.crt": generateCertificate(t, 255),
+		},
+	}
+	invalidCert := &certmanagerv1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "tracing-otlphttp-auth-cert",
+			Namespace: "cluster-1",
+			Labels:    labels,
+		},
+		Spec: certmanagerv1.CertificateSpec{
+			SecretName: "tracing-otlphttp-auth",
+		},
+	}
+	invalidCertSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "tracing-otlphttp-auth",
+			Namespace: "cluster-1",
+		},
+		Data: map[string][]byte{
+			"tls.crt": []byte("invalid"),
+		},
+	}
+	staticSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "logging-cloudwatch-auth",
+			Namespace: "cluster-1",
+			Labels:    labels,
+		},
+	}
+	userSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "user-secret",
+			Namespace: "cluster-1",
+		},
+	}
+	sharedSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "static-authentication",
+			Namespace: "open-cluster-management",
+		},
+	}
+
+	s := scheme.Scheme
+	require.NoError(t, certmanagerv1.AddToScheme(s))
+	fakeKubeClient := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(cert, certSecret, invalidCert, invalidCertSecret, staticSecret, userSecret, sharedSecret).
+		Build()
+
+	ctx := context.TODO()
+	err := RevokeCredentials(ctx, fakeKubeClient, "cluster-1", []client.ObjectKey{client.ObjectKeyFromObject(sharedSecret)})
+	require.Equal(t, &IncompleteRevocationError{
+		Cluster:      "cluster-1",
+		Certificates: []client.ObjectKey{client.ObjectKeyFromObject(invalidCertSecret)},
+		Secrets:      []client.ObjectKey{client.ObjectKeyFromObject(sharedSecret)},
+	}, err)
+
+	for _, obj := range []client.Object{cert, certSecret, invalidCert, invalidCertSecret, staticSecret} {
+		err = fakeKubeClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+		require.True(t, errors.IsNotFound(err), "expected %s to be deleted", obj.GetName())
+	}
+
+	err = fakeKubeClient.Get(ctx, client.ObjectKeyFromObject(userSecret), &corev1.Secret{})
+	require.NoError(t, err)
+
+	denyList := &corev1.ConfigMap{}
+	err = fakeKubeClient.Get(ctx, client.ObjectKey{Name: DenyListName, Namespace: "open-cluster-management"}, denyList)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"ff": "cluster-1/tracing-otlp-auth"}, denyList.Data)
+
+	got := &corev1.Secret{}
+	err = fakeKubeClient.Get(ctx, client.ObjectKeyFromObject(sharedSecret), got)
+	require.NoError(t, err)
+	require.Equal(t, "cluster-1", got.Annotations[AnnotationRotationRequired])
+}
+
+func Test_ReconcileRevocation(t *testing.T) {
+	cluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster-1",
+			Annotations: map[string]string{
+				AnnotationRevokeCredentials: "true",
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "logging-cloudwatch-auth",
+			Namespace: "cluster-1",
+			Labels: map[string]string{
+				ManagedByLabelKey: "multicluster-observability-addon",
+			},
+		},
+	}
+
+	s := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(s))
+	require.NoError(t, certmanagerv1.AddToScheme(s))
+	require.NoError(t, clusterv1.AddToScheme(s))
+	fakeKubeClient := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(cluster, secret).
+		Build()
+
+	ctx := context.TODO()
+	revoked, err := ReconcileRevocation(ctx, fakeKubeClient, cluster, nil)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	err = fakeKubeClient.Get(ctx, client.ObjectKeyFromObject(secret), &corev1.Secret{})
+	require.True(t, errors.IsNotFound(err))
+
+	err = fakeKubeClient.Get(ctx, client.ObjectKeyFromObject(cluster), cluster)
+	require.NoError(t, err)
+	require.Contains(t, cluster.Annotations, AnnotationCredentialsRevoked)
+
+	// Credentials issued after the revocation are not revoked again
+	secret.ResourceVersion = ""
+	require.NoError(t, fakeKubeClient.Create(ctx, secret))
+	revoked, err = ReconcileRevocation(ctx, fakeKubeClient, cluster, nil)
+	require.NoError(t, err)
+	require.True(t, revoked)
+	err = fakeKubeClient.Get(ctx, client.ObjectKeyFromObject(secret), &corev1.Secret{})
+	require.NoError(t, err)
+
+	// Removing the annotation removes the record of the revocation
+	delete(cluster.Annotations, AnnotationRevokeCredentials)
+	revoked, err = ReconcileRevocation(ctx, fakeKubeClient, cluster, nil)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	err = fakeKubeClient.Get(ctx, client.ObjectKeyFromObject(cluster), cluster)
+	require.NoError(t, err)
+	require.NotContains(t, cluster.Annotations, AnnotationCredentialsRevoked)
+}
+
+func Test_RequestRevocation(t *testing.T) {
+	cluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster-1",
+		},
+	}
+	sharedSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "static-authentication",
+			Namespace: "open-cluster-management",
+		},
+	}
+	sharedSecrets := []client.ObjectKey{client.ObjectKeyFromObject(sharedSecret)}
+
+	s := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(s))
+	require.NoError(t, certmanagerv1.AddToScheme(s))
+	require.NoError(t, clusterv1.AddToScheme(s))
+	fakeKubeClient := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(cluster, sharedSecret).
+		Build()
+
+	ctx := context.TODO()
+	err := RequestRevocation(ctx, fakeKubeClient, "cluster-1", sharedSecrets)
+	require.Equal(t, &IncompleteRevocationError{Cluster: "cluster-1", Secrets: sharedSecrets}, err)
+
+	err = fakeKubeClient.Get(ctx, client.ObjectKeyFromObject(cluster), cluster)
+	require.NoError(t, err)
+	require.Contains(t, cluster.Annotations, AnnotationRevokeCredentials)
+	require.Contains(t, cluster.Annotations, AnnotationCredentialsRevoked)
+
+	// The revocation fails until the shared secret is rotated
+	revoked, err := ReconcileRevocation(ctx, fakeKubeClient, cluster, sharedSecrets)
+	require.True(t, revoked)
+	require.Equal(t, &IncompleteRevocationError{Cluster: "cluster-1", Secrets: sharedSecrets}, err)
+
+	err = fakeKubeClient.Get(ctx, client.ObjectKeyFromObject(sharedSecret), sharedSecret)
+	require.NoError(t, err)
+	delete(sharedSecret.Annotations, AnnotationRotationRequired)
+	require.NoError(t, fakeKubeClient.Update(ctx, sharedSecret))
+
+	revoked, err = ReconcileRevocation(ctx, fakeKubeClient, cluster, sharedSecrets)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
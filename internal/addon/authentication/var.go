@@ -1,5 +1,7 @@
 package authentication
 
+import "time"
+
 const (
 	// Static represents static authentication type.
 	Static AuthenticationType = "StaticAuthentication"
@@ -9,6 +11,63 @@ const (
 	MTLS AuthenticationType = "mTLS"
 	// MCO represents an authentication type that will re-use the MCO provided credentials
 	MCO AuthenticationType = "MCO"
+
+	// AllTargets is the Target used to register CA bundles that apply to all targets
+	AllTargets Target = "*"
+
+	// TrustBundleName is the name of the ConfigMap holding the CA bundles of
+	// all targets on the spoke clusters
+	TrustBundleName = "mcoa-trust-bundle"
+
+	// ManagedByLabelKey is the label set on all the objects created by the
+	// authentication package, used to find them when revoking credentials
+	ManagedByLabelKey = "app.kubernetes.io/managed-by"
+
+	// AnnotationRevokeCredentials when set on a ManagedCluster triggers the
+	// revocation of all the credentials issued for it
+	AnnotationRevokeCredentials = "mcoa.openshift.io/revoke-credentials"
+	// AnnotationCredentialsRevoked is set on a ManagedCluster once the
+	// credentials requested with AnnotationRevokeCredentials were revoked
+	AnnotationCredentialsRevoked = "mcoa.openshift.io/credentials-revoked"
+	// AnnotationRotationRequired is set on shared secrets that were used by a
+	// revoked cluster
+	AnnotationRotationRequired = "mcoa.openshift.io/rotation-required"
+
+	// DenyListName is the name of the ConfigMap listing the serials of all the
+	// revoked certificates
+	DenyListName = "mcoa-revoked-certificates"
+
+	// DefaultConfigMapCAKey is the ConfigMap key read by default to get a CA bundle
+	DefaultConfigMapCAKey = "service-ca.crt"
+	// DefaultSecretCAKey is the Secret key read by default to get a CA bundle
+	DefaultSecretCAKey = "ca.crt"
+)
+
+const (
+	// CertificatesDegradedCondition is the suffix of the ManagedClusterAddOn
+	// condition type set for each signal when its certificates need attention
+	CertificatesDegradedCondition = "CertificatesDegraded"
+
+	// ReasonCertificatesValid is set when all certificates are valid
+	ReasonCertificatesValid = "CertificatesValid"
+	// ReasonCertificateExpiring is set when a certificate is close to expire
+	ReasonCertificateExpiring = "CertificateExpiring"
+	// ReasonCertificateRenewalFailed is set when a certificate failed to be renewed
+	ReasonCertificateRenewalFailed = "CertificateRenewalFailed"
+
+	// AuthConfigConflictingCondition is the suffix of the ManagedClusterAddOn
+	// condition type set for each signal when its auth ConfigMaps conflict
+	AuthConfigConflictingCondition = "AuthConfigConflicting"
+
+	// ReasonAuthConfigConsistent is set when the auth ConfigMaps agree
+	ReasonAuthConfigConsistent = "AuthConfigConsistent"
+	// ReasonAuthConfigConflict is set when auth ConfigMaps of the same
+	// precedence set a target to different authentication types
+	ReasonAuthConfigConflict = "AuthConfigConflict"
+
+	certificateExpiryWarningThreshold = 7 * 24 * time.Hour
+
+	metricsNamespace = "mcoa"
 )
 
 var certManagerCRDs = []string{"certificates.cert-manager.io", "issuers.cert-manager.io", "clusterissuers.cert-manager.io"}
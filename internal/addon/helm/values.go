@@ -4,6 +4,7 @@ import (
 	"context"
 	"strconv"
 
+	"github.com/ViaQ/logerr/v2/kverrors"
 	"github.com/rhobs/multicluster-observability-addon/internal/addon"
 	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
 	lhandlers "github.com/rhobs/multicluster-observability-addon/internal/logging/handlers"
@@ -34,14 +35,22 @@ type Options struct {
 func GetValuesFunc(k8s client.Client) addonfactory.GetValuesFunc {
 	return func(
 		cluster *clusterv1.ManagedCluster,
-		addon *addonapiv1alpha1.ManagedClusterAddOn,
+		mcAddon *addonapiv1alpha1.ManagedClusterAddOn,
 	) (addonfactory.Values, error) {
-		err := authentication.CreateOrUpdateRootCertificate(k8s)
+		revoked, err := authentication.ReconcileRevocation(context.Background(), k8s, cluster, SharedSecrets())
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, kverrors.New("credentials revoked for cluster, remove the annotation to issue new ones", "cluster", cluster.Name, "annotation", authentication.AnnotationRevokeCredentials)
+		}
+
+		err = authentication.CreateOrUpdateRootCertificate(k8s)
 		if err != nil {
 			return nil, err
 		}
 
-		aodc, err := getAddOnDeploymentConfig(k8s, addon)
+		aodc, err := getAddOnDeploymentConfig(k8s, mcAddon)
 		if err != nil {
 			return nil, err
 		}
@@ -53,7 +62,7 @@ func GetValuesFunc(k8s client.Client) addonfactory.GetValuesFunc {
 		var userValues HelmChartValues
 
 		if !opts.MetricsDisabled {
-			metrics, err := metrics.GetValuesFunc(k8s, cluster, addon, aodc)
+			metrics, err := metrics.GetValuesFunc(k8s, cluster, mcAddon, aodc)
 			if err != nil {
 				return nil, err
 			}
@@ -61,12 +70,17 @@ func GetValuesFunc(k8s client.Client) addonfactory.GetValuesFunc {
 		}
 
 		if !opts.LoggingDisabled {
-			loggingOpts, err := lhandlers.BuildOptions(k8s, addon, aodc)
+			loggingOpts, err := lhandlers.BuildOptions(k8s, cluster, mcAddon, aodc)
 			if err != nil {
 				return nil, err
 			}
 
 			logging, err := lmanifests.BuildValues(loggingOpts)
+			if condition, ok := addon.ValidationCondition(lmanifests.ConditionTypeLoggingConfigurationDegraded, err); ok {
+				if condErr := addon.UpdateCondition(context.Background(), k8s, mcAddon, condition); condErr != nil {
+					klog.Error(condErr, "failed to report logging validation status")
+				}
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -75,22 +89,50 @@ func GetValuesFunc(k8s client.Client) addonfactory.GetValuesFunc {
 
 		if !opts.TracingDisabled {
 			klog.Info("Tracing enabled")
-			tracingOpts, err := thandlers.BuildOptions(k8s, addon, aodc)
+			tracing, err := buildTracingValues(k8s, cluster, mcAddon, aodc)
+			if condition, ok := addon.ValidationCondition(tmanifests.ConditionTypeTracingConfigurationDegraded, err); ok {
+				if condErr := addon.UpdateCondition(context.Background(), k8s, mcAddon, condition); condErr != nil {
+					klog.Error(condErr, "failed to report tracing validation status")
+				}
+			}
 			if err != nil {
 				return nil, err
 			}
+			userValues.Tracing = tracing
 
-			tracing, err := tmanifests.BuildValues(tracingOpts)
+			// Not a validation error, the Instrumentation is still rendered
+			// in the namespaces that exist
+			condition, err := thandlers.InstrumentationCondition(k8s, cluster.Name, tracing.InstrumentationNamespaces)
 			if err != nil {
-				return nil, err
+				klog.Error(err, "failed to read the instrumentation status")
+			} else if condErr := addon.UpdateCondition(context.Background(), k8s, mcAddon, condition); condErr != nil {
+				klog.Error(condErr, "failed to report instrumentation status")
 			}
-			userValues.Tracing = tracing
 		}
 
 		return addonfactory.JsonStructToValues(userValues)
 	}
 }
 
+// buildTracingValues builds the tracing values of the cluster. The templates
+// referenced by the addon are validated when building the options too, both
+// steps can return a ValidationError.
+func buildTracingValues(k8s client.Client, cluster *clusterv1.ManagedCluster, mcAddon *addonapiv1alpha1.ManagedClusterAddOn, aodc *addonapiv1alpha1.AddOnDeploymentConfig) (tmanifests.TracingValues, error) {
+	opts, err := thandlers.BuildOptions(k8s, cluster, mcAddon, aodc)
+	if err != nil {
+		return tmanifests.TracingValues{}, err
+	}
+	return tmanifests.BuildValues(opts)
+}
+
+// SharedSecrets returns the keys of the secrets that are shared by all the
+// clusters to build their credentials
+func SharedSecrets() []client.ObjectKey {
+	return []client.ObjectKey{
+		lmanifests.AuthDefaultConfig.StaticAuthConfig.ExistingSecret,
+	}
+}
+
 func getAddOnDeploymentConfig(k8s client.Client, mcAddon *addonapiv1alpha1.ManagedClusterAddOn) (*addonapiv1alpha1.AddOnDeploymentConfig, error) {
 	key := addon.GetObjectKey(mcAddon.Status.ConfigReferences, addonutils.AddOnDeploymentConfigGVR.Group, addon.AddonDeploymentConfigResource)
 	addOnDeployment := &addonapiv1alpha1.AddOnDeploymentConfig{}
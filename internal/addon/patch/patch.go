@@ -0,0 +1,178 @@
+package patch
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	jsonpatch "github.com/evanphx/json-patch"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	"sigs.k8s.io/yaml"
+)
+
+// Type defines how a patch is applied to a template
+type Type string
+
+const (
+	// TypeJSON is a RFC 6902 JSON patch
+	TypeJSON Type = "json"
+	// TypeStrategicMerge is a Kubernetes strategic merge patch. Templates
+	// without a Go type to read the patch strategy from are merged like a
+	// RFC 7386 JSON merge patch.
+	TypeStrategicMerge Type = "strategic-merge"
+
+	// AnnotationClusters restricts a patch to a comma separated list of
+	// ManagedClusters
+	AnnotationClusters = "mcoa.openshift.io/patch-clusters"
+	// AnnotationClusterSets restricts a patch to the ManagedClusters of a
+	// comma separated list of ManagedClusterSets
+	AnnotationClusterSets = "mcoa.openshift.io/patch-clustersets"
+)
+
+// scope orders ConfigMaps from the broadest to the most specific so that the
+// ones targeting a single cluster have the last word.
+type scope int
+
+const (
+	scopeAll scope = iota
+	scopeClusterSet
+	scopeCluster
+)
+
+// Patch holds the patches found in a ConfigMap. The keys of the ConfigMap are
+// the names of the templates to patch and the values are the patches, in
+// YAML or JSON.
+type Patch struct {
+	ConfigMap string
+	Type      Type
+	Data      map[string]string
+}
+
+// ForCluster returns the patches of the ConfigMaps that apply to the cluster
+// in the order they must be applied, as returned by SelectConfigMaps. The type
+// of the patch is read from the typeAnnotation.
+func ForCluster(cms []corev1.ConfigMap, typeAnnotation string, cluster *clusterv1.ManagedCluster) ([]Patch, error) {
+	for _, cm := range cms {
+		patchType := Type(cm.Annotations[typeAnnotation])
+		switch patchType {
+		case TypeJSON, TypeStrategicMerge:
+		default:
+			return nil, kverrors.New("invalid patch type in configmap", "name", cm.Name, "namespace", cm.Namespace, "type", patchType)
+		}
+	}
+
+	var patches []Patch
+	for _, cm := range SelectConfigMaps(cms, cluster) {
+		patches = append(patches, Patch{
+			ConfigMap: cm.Name,
+			Type:      Type(cm.Annotations[typeAnnotation]),
+			Data:      cm.Data,
+		})
+	}
+	return patches, nil
+}
+
+// SelectConfigMaps returns the ConfigMaps that apply to the cluster ordered
+// from the broadest to the most specific. ConfigMaps in the namespace of the
+// cluster, or listing it in AnnotationClusters, come last, after the ones
+// listing one of its ManagedClusterSets in AnnotationClusterSets, themselves
+// after the ones without restriction. ConfigMaps of the same scope are ordered
+// by name.
+func SelectConfigMaps(cms []corev1.ConfigMap, cluster *clusterv1.ManagedCluster) []corev1.ConfigMap {
+	type scoped struct {
+		cm    corev1.ConfigMap
+		scope scope
+	}
+
+	var selected []scoped
+	for _, cm := range cms {
+		s, ok := clusterScope(cm, cluster)
+		if !ok {
+			continue
+		}
+		selected = append(selected, scoped{cm: cm, scope: s})
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		if selected[i].scope != selected[j].scope {
+			return selected[i].scope < selected[j].scope
+		}
+		return selected[i].cm.Name < selected[j].cm.Name
+	})
+
+	result := make([]corev1.ConfigMap, 0, len(selected))
+	for _, s := range selected {
+		result = append(result, s.cm)
+	}
+	return result
+}
+
+func clusterScope(cm corev1.ConfigMap, cluster *clusterv1.ManagedCluster) (scope, bool) {
+	if cluster == nil {
+		return scopeAll, true
+	}
+
+	clusters, hasClusters := cm.Annotations[AnnotationClusters]
+	clusterSets, hasClusterSets := cm.Annotations[AnnotationClusterSets]
+
+	switch {
+	case cm.Namespace == cluster.Name || (hasClusters && contains(clusters, cluster.Name)):
+		return scopeCluster, true
+	case hasClusterSets && contains(clusterSets, cluster.Labels[clusterv1beta2.ClusterSetLabel]):
+		return scopeClusterSet, true
+	case !hasClusters && !hasClusterSets:
+		return scopeAll, true
+	}
+	return scopeAll, false
+}
+
+func contains(list, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, item := range strings.Split(list, ",") {
+		if strings.TrimSpace(item) == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply applies to the JSON document of the template the patches targeting it
+// by name. schema is the Go type of the document used by strategic merge
+// patches, when nil they are applied as JSON merge patches.
+func Apply(doc []byte, name string, patches []Patch, schema interface{}) ([]byte, error) {
+	for _, p := range patches {
+		data, ok := p.Data[name]
+		if !ok {
+			continue
+		}
+
+		patchJSON, err := yaml.YAMLToJSON([]byte(data))
+		if err != nil {
+			return nil, kverrors.Wrap(err, "failed to read patch", "configmap", p.ConfigMap, "template", name)
+		}
+
+		switch p.Type {
+		case TypeJSON:
+			var ops jsonpatch.Patch
+			ops, err = jsonpatch.DecodePatch(patchJSON)
+			if err == nil {
+				doc, err = ops.Apply(doc)
+			}
+		case TypeStrategicMerge:
+			if schema != nil {
+				doc, err = strategicpatch.StrategicMergePatch(doc, patchJSON, schema)
+			} else {
+				doc, err = jsonpatch.MergePatch(doc, patchJSON)
+			}
+		}
+		if err != nil {
+			return nil, kverrors.Wrap(err, "failed to apply patch", "configmap", p.ConfigMap, "template", name)
+		}
+	}
+	return doc, nil
+}
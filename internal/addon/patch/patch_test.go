@@ -0,0 +1,149 @@
+package patch
+
+import (
+	"testing"
+
+	loggingv1 "github.com/openshift/cluster-logging-operator/apis/logging/v1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+)
+
+const typeAnnotation = "foo.mcoa.openshift.io/patch"
+
+func patchCM(namespace, name string, annotations map[string]string) corev1.ConfigMap {
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if _, ok := annotations[typeAnnotation]; !ok {
+		annotations[typeAnnotation] = string(TypeJSON)
+	}
+	return corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: annotations,
+		},
+	}
+}
+
+func Test_ForCluster(t *testing.T) {
+	cluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster-1",
+			Labels: map[string]string{
+				clusterv1beta2.ClusterSetLabel: "prod",
+			},
+		},
+	}
+
+	cms := []corev1.ConfigMap{
+		patchCM("cluster-1", "own-namespace", nil),
+		patchCM("open-cluster-management", "listed-cluster", map[string]string{AnnotationClusters: "cluster-0, cluster-1"}),
+		patchCM("open-cluster-management", "other-cluster", map[string]string{AnnotationClusters: "cluster-2"}),
+		patchCM("open-cluster-management", "prod-set", map[string]string{AnnotationClusterSets: "prod"}),
+		patchCM("open-cluster-management", "dev-set", map[string]string{AnnotationClusterSets: "dev"}),
+		patchCM("open-cluster-management", "b-all", nil),
+		patchCM("open-cluster-management", "a-all", nil),
+	}
+
+	patches, err := ForCluster(cms, typeAnnotation, cluster)
+	require.NoError(t, err)
+
+	var names []string
+	for _, p := range patches {
+		names = append(names, p.ConfigMap)
+	}
+	require.Equal(t, []string{"a-all", "b-all", "prod-set", "listed-cluster", "own-namespace"}, names)
+}
+
+func Test_ForCluster_InvalidType(t *testing.T) {
+	cms := []corev1.ConfigMap{
+		patchCM("open-cluster-management", "invalid", map[string]string{typeAnnotation: "merge"}),
+	}
+
+	_, err := ForCluster(cms, typeAnnotation, nil)
+	require.Error(t, err)
+}
+
+func Test_Apply(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		patches  []Patch
+		schema   interface{}
+		expected string
+	}{
+		{
+			name: "json patch",
+			patches: []Patch{
+				{
+					Type: TypeJSON,
+					Data: map[string]string{
+						"instance": `[{"op": "add", "path": "/pipelines/-", "value": {"name": "audit"}}]`,
+					},
+				},
+			},
+			expected: `{"pipelines":[{"name":"app"},{"name":"audit"}]}`,
+		},
+		{
+			name: "strategic merge patch in yaml",
+			patches: []Patch{
+				{
+					Type: TypeStrategicMerge,
+					Data: map[string]string{
+						"instance": "serviceAccountName: collector",
+					},
+				},
+			},
+			schema:   loggingv1.ClusterLogForwarderSpec{},
+			expected: `{"pipelines":[{"name":"app"}],"serviceAccountName":"collector"}`,
+		},
+		{
+			name: "merge patch without schema",
+			patches: []Patch{
+				{
+					Type: TypeStrategicMerge,
+					Data: map[string]string{
+						"instance": `{"pipelines": null}`,
+					},
+				},
+			},
+			expected: `{}`,
+		},
+		{
+			name: "patches of other templates are ignored",
+			patches: []Patch{
+				{
+					Type: TypeJSON,
+					Data: map[string]string{
+						"other": `[{"op": "remove", "path": "/pipelines"}]`,
+					},
+				},
+			},
+			expected: `{"pipelines":[{"name":"app"}]}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := Apply([]byte(`{"pipelines":[{"name":"app"}]}`), "instance", tc.patches, tc.schema)
+			require.NoError(t, err)
+			require.JSONEq(t, tc.expected, string(doc))
+		})
+	}
+}
+
+func Test_Apply_Invalid(t *testing.T) {
+	patches := []Patch{
+		{
+			ConfigMap: "invalid",
+			Type:      TypeJSON,
+			Data: map[string]string{
+				"instance": `[{"op": "remove", "path": "/outputs"}]`,
+			},
+		},
+	}
+
+	_, err := Apply([]byte(`{}`), "instance", patches, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,25 @@
+package addon
+
+import (
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// NewRenderScheme returns the scheme used to decode the manifests rendered by
+// the chart. It knows the types of the given scheme and decodes the kinds
+// whose Go types are not vendored as unstructured objects. The client doesn't
+// need these kinds since it handles unstructured objects by their GVK, so they
+// are kept out of the scheme it uses.
+func NewRenderScheme(s *runtime.Scheme, unstructuredKinds ...schema.GroupVersionKind) *runtime.Scheme {
+	rs := runtime.NewScheme()
+	for gvk, t := range s.AllKnownTypes() {
+		rs.AddKnownTypeWithName(gvk, reflect.New(t).Interface().(runtime.Object))
+	}
+	for _, gvk := range unstructuredKinds {
+		rs.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+	}
+	return rs
+}
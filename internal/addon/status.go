@@ -0,0 +1,37 @@
+package addon
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// UpdateCondition sets the condition on the status of the ManagedClusterAddOn.
+// The ManagedClusterAddOn is fetched again before patching so that conditions
+// set since it was cached are kept, the patch is retried on conflicts. The
+// status is only patched when the condition changed.
+func UpdateCondition(ctx context.Context, k8s client.Client, mcAddon *addonapiv1alpha1.ManagedClusterAddOn, condition metav1.Condition) error {
+	key := client.ObjectKeyFromObject(mcAddon)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := &addonapiv1alpha1.ManagedClusterAddOn{}
+		if err := k8s.Get(ctx, key, current, &client.GetOptions{}); err != nil {
+			return err
+		}
+
+		existing := meta.FindStatusCondition(current.Status.Conditions, condition.Type)
+		if existing != nil &&
+			existing.Status == condition.Status &&
+			existing.Reason == condition.Reason &&
+			existing.Message == condition.Message {
+			return nil
+		}
+
+		updated := current.DeepCopy()
+		meta.SetStatusCondition(&updated.Status.Conditions, condition)
+		return k8s.Status().Patch(ctx, updated, client.MergeFromWithOptions(current, client.MergeFromWithOptimisticLock{}))
+	})
+}
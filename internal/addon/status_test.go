@@ -0,0 +1,44 @@
+package addon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"open-cluster-management.io/addon-framework/pkg/addonmanager/addontesting"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_UpdateCondition(t *testing.T) {
+	mcAddon := addontesting.NewAddon("test", "cluster-1")
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, addonapiv1alpha1.AddToScheme(scheme))
+	fakeKubeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(mcAddon).
+		WithStatusSubresource(mcAddon).
+		Build()
+
+	// Both conditions are set from the same cached ManagedClusterAddOn
+	stale := mcAddon.DeepCopy()
+	for _, conditionType := range []string{"LoggingConfigurationDegraded", "TracingConfigurationDegraded"} {
+		err := UpdateCondition(context.TODO(), fakeKubeClient, stale, metav1.Condition{
+			Type:   conditionType,
+			Status: metav1.ConditionFalse,
+			Reason: "ConfigurationValid",
+		})
+		require.NoError(t, err)
+	}
+
+	got := &addonapiv1alpha1.ManagedClusterAddOn{}
+	err := fakeKubeClient.Get(context.TODO(), client.ObjectKeyFromObject(mcAddon), got)
+	require.NoError(t, err)
+	require.NotNil(t, meta.FindStatusCondition(got.Status.Conditions, "LoggingConfigurationDegraded"))
+	require.NotNil(t, meta.FindStatusCondition(got.Status.Conditions, "TracingConfigurationDegraded"))
+}
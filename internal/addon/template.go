@@ -0,0 +1,138 @@
+package addon
+
+import (
+	"encoding/json"
+	"strings"
+	"text/template"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+const (
+	// templateDelimiter marks the strings of a template that hold expressions
+	templateDelimiter = "{{"
+
+	// DefaultTenantTemplate is the tenant of the clusters when the ConfigMap
+	// configuring an output or an exporter doesn't set one
+	DefaultTenantTemplate = "{{ .ClusterName }}"
+)
+
+// ClusterValues are the variables of a ManagedCluster that can be used in the
+// expressions of the templates referenced by the ManagedClusterAddOn, e.g.
+// {{ .ClusterName }}, {{ .Labels.env }} or
+// {{ .ClusterClaims "region.open-cluster-management.io" }}.
+type ClusterValues struct {
+	ClusterName string
+	Labels      map[string]string
+	claims      map[string]string
+}
+
+// NewClusterValues returns the variables of the cluster. The ManagedCluster
+// can be nil, in which case only the cluster name is available.
+func NewClusterValues(clusterName string, cluster *clusterv1.ManagedCluster) ClusterValues {
+	values := ClusterValues{
+		ClusterName: clusterName,
+		Labels:      map[string]string{},
+		claims:      map[string]string{},
+	}
+	if cluster == nil {
+		return values
+	}
+
+	for k, v := range cluster.Labels {
+		values.Labels[k] = v
+	}
+	for _, claim := range cluster.Status.ClusterClaims {
+		values.claims[claim.Name] = claim.Value
+	}
+	return values
+}
+
+// ClusterClaims returns the value of the ClusterClaim, it fails when the
+// cluster doesn't report it.
+func (v ClusterValues) ClusterClaims(name string) (string, error) {
+	value, ok := v.claims[name]
+	if !ok {
+		return "", kverrors.New("cluster claim not found", "cluster", v.ClusterName, "claim", name)
+	}
+	return value, nil
+}
+
+// RenderClusterTemplate evaluates the expressions of every string of obj with
+// the variables of the cluster. obj must be a pointer to a value that can be
+// encoded to JSON. Referencing a missing label or claim is an error. Like in
+// unstructured objects, the integers of untyped values are decoded as int64.
+func RenderClusterTemplate(obj interface{}, values ClusterValues) error {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	var doc interface{}
+	if err := utiljson.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+
+	doc, err = renderValue(doc, values)
+	if err != nil {
+		return err
+	}
+
+	b, err = json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return utiljson.Unmarshal(b, obj)
+}
+
+// RenderTenant renders the tenant template of an output or an exporter with
+// the variables of the cluster, the tenant can't be empty.
+func RenderTenant(text string, values ClusterValues) (string, error) {
+	tenant := text
+	if err := RenderClusterTemplate(&tenant, values); err != nil {
+		return "", err
+	}
+	if tenant == "" {
+		return "", kverrors.New("tenant template rendered an empty tenant", "template", text)
+	}
+	return tenant, nil
+}
+
+func renderValue(value interface{}, values ClusterValues) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			rendered, err := renderValue(item, values)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = rendered
+		}
+	case []interface{}:
+		for i, item := range v {
+			rendered, err := renderValue(item, values)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = rendered
+		}
+	case string:
+		if !strings.Contains(v, templateDelimiter) {
+			return v, nil
+		}
+
+		tmpl, err := template.New("").Option("missingkey=error").Parse(v)
+		if err != nil {
+			return nil, kverrors.Wrap(err, "invalid template expression", "value", v)
+		}
+
+		var out strings.Builder
+		if err := tmpl.Execute(&out, values); err != nil {
+			return nil, kverrors.Wrap(err, "failed to render template expression", "value", v)
+		}
+		return out.String(), nil
+	}
+	return value, nil
+}
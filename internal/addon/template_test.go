@@ -0,0 +1,101 @@
+package addon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+func Test_RenderClusterTemplate(t *testing.T) {
+	cluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster-1",
+			Labels: map[string]string{
+				"env": "prod",
+			},
+		},
+		Status: clusterv1.ManagedClusterStatus{
+			ClusterClaims: []clusterv1.ManagedClusterClaim{
+				{Name: "region.open-cluster-management.io", Value: "eu-west-1"},
+			},
+		},
+	}
+	values := NewClusterValues("cluster-1", cluster)
+
+	type spec struct {
+		URL     string            `json:"url"`
+		Headers map[string]string `json:"headers"`
+		Config  string            `json:"config"`
+		Port    int               `json:"port"`
+	}
+
+	for _, tc := range []struct {
+		name     string
+		in       spec
+		expected spec
+		wantErr  bool
+	}{
+		{
+			name: "cluster variables",
+			in: spec{
+				URL:     "https://loki/api/logs/v1/{{ .ClusterName }}",
+				Headers: map[string]string{"X-Env": "{{ .Labels.env }}"},
+				Config:  "region: {{ .ClusterClaims \"region.open-cluster-management.io\" }}\n",
+				Port:    443,
+			},
+			expected: spec{
+				URL:     "https://loki/api/logs/v1/cluster-1",
+				Headers: map[string]string{"X-Env": "prod"},
+				Config:  "region: eu-west-1\n",
+				Port:    443,
+			},
+		},
+		{
+			name:    "missing label",
+			in:      spec{URL: "{{ .Labels.missing }}"},
+			wantErr: true,
+		},
+		{
+			name:    "missing claim",
+			in:      spec{URL: "{{ .ClusterClaims \"id.openshift.io\" }}"},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := tc.in
+			err := RenderClusterTemplate(&obj, values)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, obj)
+		})
+	}
+}
+
+func Test_RenderClusterTemplate_Unstructured(t *testing.T) {
+	values := NewClusterValues("cluster-1", &clusterv1.ManagedCluster{})
+
+	var spec interface{} = map[string]interface{}{
+		"replicas": int64(3),
+		"config": map[string]interface{}{
+			"endpoint":        "https://tempo/{{ .ClusterName }}",
+			"send_batch_size": int64(1000000),
+			"ratio":           0.5,
+		},
+	}
+
+	err := RenderClusterTemplate(&spec, values)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"replicas": int64(3),
+		"config": map[string]interface{}{
+			"endpoint":        "https://tempo/cluster-1",
+			"send_batch_size": int64(1000000),
+			"ratio":           0.5,
+		},
+	}, spec)
+}
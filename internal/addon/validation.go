@@ -0,0 +1,57 @@
+package addon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ReasonConfigurationValid   = "ConfigurationValid"
+	ReasonConfigurationInvalid = "ConfigurationInvalid"
+)
+
+// ValidationError aggregates all the findings of validating a templated
+// resource
+type ValidationError struct {
+	// Kind of the validated resource
+	Kind string
+	// Name of the invalid resource, empty when the findings are not specific
+	// to a resource
+	Name     string
+	Findings []string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("invalid %s: %s", e.Kind, strings.Join(e.Findings, "; "))
+	}
+	return fmt.Sprintf("invalid %s %s: %s", e.Kind, e.Name, strings.Join(e.Findings, "; "))
+}
+
+// ValidationCondition returns the ManagedClusterAddOn condition of the given
+// type reporting the result of a validation. It returns false when err is not
+// a ValidationError since the configuration could not be validated and the
+// condition must be left unchanged.
+func ValidationCondition(conditionType string, err error) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:    conditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  ReasonConfigurationValid,
+		Message: "Configuration is valid",
+	}
+	if err == nil {
+		return condition, true
+	}
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		return condition, false
+	}
+	condition.Status = metav1.ConditionTrue
+	condition.Reason = ReasonConfigurationInvalid
+	condition.Message = verr.Error()
+	return condition, true
+}
@@ -0,0 +1,57 @@
+package addon
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_ValidationError(t *testing.T) {
+	err := &ValidationError{Kind: "ClusterLogForwarder", Findings: []string{"a", "b"}}
+	require.Equal(t, "invalid ClusterLogForwarder: a; b", err.Error())
+
+	err.Name = "audit"
+	require.Equal(t, "invalid ClusterLogForwarder audit: a; b", err.Error())
+}
+
+func Test_ValidationCondition(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		err            error
+		expectedOK     bool
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "valid",
+			expectedOK:     true,
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: ReasonConfigurationValid,
+		},
+		{
+			name:           "invalid",
+			err:            &ValidationError{Kind: "OpenTelemetryCollector", Findings: []string{"a"}},
+			expectedOK:     true,
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: ReasonConfigurationInvalid,
+		},
+		{
+			// Errors unrelated to the validation leave the condition unchanged
+			name: "not a validation error",
+			err:  errors.New("failed to get secret"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			condition, ok := ValidationCondition("TracingConfigurationDegraded", tc.err)
+			require.Equal(t, tc.expectedOK, ok)
+			if !ok {
+				return
+			}
+			require.Equal(t, "TracingConfigurationDegraded", condition.Type)
+			require.Equal(t, tc.expectedStatus, condition.Status)
+			require.Equal(t, tc.expectedReason, condition.Reason)
+		})
+	}
+}
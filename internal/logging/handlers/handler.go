@@ -7,9 +7,13 @@ import (
 	loggingv1 "github.com/openshift/cluster-logging-operator/apis/logging/v1"
 	"github.com/rhobs/multicluster-observability-addon/internal/addon"
 	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/patch"
 	"github.com/rhobs/multicluster-observability-addon/internal/logging/manifests"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
 	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -17,25 +21,62 @@ const (
 	clusterLogForwarderResource = "clusterlogforwarders"
 )
 
-func BuildOptions(k8s client.Client, mcAddon *addonapiv1alpha1.ManagedClusterAddOn, adoc *addonapiv1alpha1.AddOnDeploymentConfig) (manifests.Options, error) {
+func BuildOptions(k8s client.Client, cluster *clusterv1.ManagedCluster, mcAddon *addonapiv1alpha1.ManagedClusterAddOn, adoc *addonapiv1alpha1.AddOnDeploymentConfig) (manifests.Options, error) {
 	resources := manifests.Options{
+		ClusterName:           mcAddon.Namespace,
+		ManagedCluster:        cluster,
 		AddOnDeploymentConfig: adoc,
 	}
 
-	key := addon.GetObjectKey(mcAddon.Status.ConfigReferences, loggingv1.GroupVersion.Group, clusterLogForwarderResource)
-	clf := &loggingv1.ClusterLogForwarder{}
-	if err := k8s.Get(context.Background(), key, clf, &client.GetOptions{}); err != nil {
+	// Templates can use expressions evaluated with the variables of the cluster
+	values := addon.NewClusterValues(mcAddon.Namespace, cluster)
+
+	// Logging 6 only serves the observability.openshift.io API, the
+	// subscription channel decides which ClusterLogForwarders are used as
+	// templates. Each template is rendered as a separate forwarder.
+	if manifests.UseObservabilityAPI(resources) {
+		keys := addon.GetObjectKeys(mcAddon.Status.ConfigReferences, manifests.ObservabilityClusterLogForwarderGVK.Group, clusterLogForwarderResource)
+		for _, key := range keys {
+			clf := unstructured.Unstructured{}
+			clf.SetGroupVersionKind(manifests.ObservabilityClusterLogForwarderGVK)
+			if err := k8s.Get(context.Background(), key, &clf, &client.GetOptions{}); err != nil {
+				return resources, err
+			}
+			if spec, ok := clf.Object["spec"]; ok {
+				if err := addon.RenderClusterTemplate(&spec, values); err != nil {
+					return resources, kverrors.Wrap(err, "failed to render ClusterLogForwarder template", "name", clf.GetName())
+				}
+				clf.Object["spec"] = spec
+			}
+			resources.ObservabilityClusterLogForwarders = append(resources.ObservabilityClusterLogForwarders, clf)
+		}
+	} else {
+		keys := addon.GetObjectKeys(mcAddon.Status.ConfigReferences, loggingv1.GroupVersion.Group, clusterLogForwarderResource)
+		for _, key := range keys {
+			clf := loggingv1.ClusterLogForwarder{}
+			if err := k8s.Get(context.Background(), key, &clf, &client.GetOptions{}); err != nil {
+				return resources, err
+			}
+			if err := addon.RenderClusterTemplate(&clf.Spec, values); err != nil {
+				return resources, kverrors.Wrap(err, "failed to render ClusterLogForwarder template", "name", clf.Name)
+			}
+			resources.ClusterLogForwarders = append(resources.ClusterLogForwarders, clf)
+		}
+	}
+
+	gateway, err := discoverLokiStackGateway(k8s, adoc)
+	if err != nil {
 		return resources, err
 	}
-	resources.ClusterLogForwarder = clf
+	resources.LokiStackGateway = gateway
 
-	authCM := &corev1.ConfigMap{}
-	caCM := &corev1.ConfigMap{}
+	var authCMs, patchCMs []corev1.ConfigMap
+	caBundles := authentication.CABundles{}
 	for _, config := range mcAddon.Spec.Configs {
+		key := client.ObjectKey{Name: config.Name, Namespace: config.Namespace}
 		switch config.ConfigGroupResource.Resource {
 		case addon.ConfigMapResource:
 			cm := &corev1.ConfigMap{}
-			key := client.ObjectKey{Name: config.Name, Namespace: config.Namespace}
 			if err := k8s.Get(context.Background(), key, cm, &client.GetOptions{}); err != nil {
 				return resources, err
 			}
@@ -45,42 +86,80 @@ func BuildOptions(k8s client.Client, mcAddon *addonapiv1alpha1.ManagedClusterAdd
 				continue
 			}
 
-			// If a cm has the ca annotation then it's the configmap containing the ca
+			// If a cm has the ca annotation then it's a configmap containing a ca
 			if _, ok := cm.Annotations[manifests.AnnotationCAToInject]; ok {
-				caCM = cm
+				if err := authentication.AddCAFromConfigMap(caBundles, cm, manifests.AnnotationCATargets, manifests.AnnotationCAKey); err != nil {
+					return resources, err
+				}
+				continue
+			}
+
+			// If a cm has the patch annotation then it's patching the ClusterLogForwarders
+			if _, ok := cm.Annotations[manifests.AnnotationPatch]; ok {
+				patchCMs = append(patchCMs, *cm)
 				continue
 			}
 
 			// If a cm doesn't have a target label then it's configuring authentication
 			if _, ok := cm.Annotations[manifests.AnnotationTargetOutputName]; !ok {
-				authCM = cm
+				authCMs = append(authCMs, *cm)
 				continue
 			}
 
 			resources.ConfigMaps = append(resources.ConfigMaps, *cm)
+		case addon.SecretResource:
+			secret := &corev1.Secret{}
+			if err := k8s.Get(context.Background(), key, secret, &client.GetOptions{}); err != nil {
+				return resources, err
+			}
+
+			// Only care about secrets's that configure logging
+			if signal, ok := secret.Labels[addon.SignalLabelKey]; !ok || signal != addon.Logging.String() {
+				continue
+			}
+
+			// If the secret has the ca annotation then it's a secret containing a ca
+			if _, ok := secret.Annotations[manifests.AnnotationCAToInject]; ok {
+				if err := authentication.AddCAFromSecret(caBundles, secret, manifests.AnnotationCATargets, manifests.AnnotationCAKey); err != nil {
+					return resources, err
+				}
+			}
 		}
 	}
 
+	resources.Patches, err = patch.ForCluster(patchCMs, manifests.AnnotationPatch, cluster)
+	if err != nil {
+		return resources, err
+	}
+
 	ctx := context.Background()
 	authConfig := manifests.AuthDefaultConfig
 	authConfig.MTLSConfig.CommonName = mcAddon.Namespace
-	if len(caCM.Data) > 0 {
-		if ca, ok := caCM.Data["service-ca.crt"]; ok {
-			authConfig.MTLSConfig.CAToInject = ca
-		} else {
-			return resources, kverrors.New("missing ca bundle in configmap", "key", "service-ca.crt")
-		}
+
+	// Auth ConfigMaps in the cluster namespace override the defaults
+	targetAuthType, conflicts := authentication.MergeAuthConfigMaps(mcAddon.Namespace, authCMs)
+	if err := authentication.ReportAuthConfigConflicts(ctx, k8s, mcAddon, addon.Logging, conflicts); err != nil {
+		klog.Error(err, "failed to report auth configmaps conflicts")
 	}
+	resources.CABundles = caBundles.Resolve(authentication.MTLSTargets(targetAuthType)...)
 
 	secretsProvider, err := authentication.NewSecretsProvider(k8s, mcAddon.Namespace, addon.Logging, authConfig)
 	if err != nil {
 		return resources, err
 	}
 
-	targetsSecret, err := secretsProvider.GenerateSecrets(ctx, authentication.BuildAuthenticationMap(authCM.Data))
+	targetsSecret, err := secretsProvider.GenerateSecrets(ctx, targetAuthType)
+	if err != nil {
+		return resources, err
+	}
+
+	statuses, err := secretsProvider.CertificatesStatus(ctx, targetAuthType, targetsSecret)
 	if err != nil {
 		return resources, err
 	}
+	if err := secretsProvider.ReportCertificates(ctx, mcAddon, statuses); err != nil {
+		klog.Error(err, "failed to report certificates status")
+	}
 
 	resources.Secrets, err = secretsProvider.FetchSecrets(ctx, targetsSecret, manifests.AnnotationTargetOutputName)
 	if err != nil {
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
+	"github.com/rhobs/multicluster-observability-addon/internal/logging/manifests"
+	corev1 "k8s.io/api/core/v1"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AddOnDeploymentConfig variables enabling the discovery of the LokiStack
+	// gateway, both expect a <namespace>/<name> reference
+	lokiStackGatewayRouteKey = "loggingLokiStackGatewayRoute"
+	lokiStackGatewayCAKey    = "loggingLokiStackGatewayCA"
+)
+
+// discoverLokiStackGateway looks up the LokiStack gateway Route configured in
+// the AddOnDeploymentConfig. The gateway CA is read from the ConfigMap
+// configured in the AddOnDeploymentConfig or, if none is set, from the Route
+// TLS configuration. Returns nil when the discovery is not configured.
+func discoverLokiStackGateway(k8s client.Client, adoc *addonapiv1alpha1.AddOnDeploymentConfig) (*manifests.LokiStackGateway, error) {
+	routeRef := addon.CustomizedVariable(adoc, lokiStackGatewayRouteKey)
+	if routeRef == "" {
+		return nil, nil
+	}
+
+	routeKey, err := addon.ParseObjectKey(routeRef)
+	if err != nil {
+		return nil, err
+	}
+
+	route := &routev1.Route{}
+	if err := k8s.Get(context.Background(), routeKey, route, &client.GetOptions{}); err != nil {
+		return nil, kverrors.Wrap(err, "failed to get LokiStack gateway route", "name", routeKey.Name, "namespace", routeKey.Namespace)
+	}
+
+	gateway := &manifests.LokiStackGateway{
+		URL: fmt.Sprintf("https://%s/api/logs/v1", route.Spec.Host),
+	}
+
+	if route.Spec.TLS != nil {
+		gateway.CA = route.Spec.TLS.CACertificate
+	}
+
+	if caRef := addon.CustomizedVariable(adoc, lokiStackGatewayCAKey); caRef != "" {
+		caKey, err := addon.ParseObjectKey(caRef)
+		if err != nil {
+			return nil, err
+		}
+
+		cm := &corev1.ConfigMap{}
+		if err := k8s.Get(context.Background(), caKey, cm, &client.GetOptions{}); err != nil {
+			return nil, kverrors.Wrap(err, "failed to get LokiStack gateway CA", "name", caKey.Name, "namespace", caKey.Namespace)
+		}
+
+		ca, ok := cm.Data[authentication.DefaultConfigMapCAKey]
+		if !ok {
+			return nil, kverrors.New("missing ca bundle in configmap", "name", cm.Name, "namespace", cm.Namespace, "key", authentication.DefaultConfigMapCAKey)
+		}
+		gateway.CA = ca
+	}
+
+	return gateway, nil
+}
@@ -5,6 +5,7 @@ import (
 
 	loggingv1 "github.com/openshift/cluster-logging-operator/apis/logging/v1"
 	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
 	"github.com/rhobs/multicluster-observability-addon/internal/logging/handlers"
 	"github.com/rhobs/multicluster-observability-addon/internal/logging/manifests"
 
@@ -13,7 +14,9 @@ import (
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/ptr"
@@ -38,7 +41,26 @@ func fakeGetValues(k8s client.Client) addonfactory.GetValuesFunc {
 		cluster *clusterv1.ManagedCluster,
 		addon *addonapiv1alpha1.ManagedClusterAddOn,
 	) (addonfactory.Values, error) {
-		opts, err := handlers.BuildOptions(k8s, addon, nil)
+		opts, err := handlers.BuildOptions(k8s, cluster, addon, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		logging, err := manifests.BuildValues(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		return addonfactory.JsonStructToValues(logging)
+	}
+}
+
+func fakeGetValuesWithConfig(k8s client.Client, adoc *addonapiv1alpha1.AddOnDeploymentConfig) addonfactory.GetValuesFunc {
+	return func(
+		cluster *clusterv1.ManagedCluster,
+		addon *addonapiv1alpha1.ManagedClusterAddOn,
+	) (addonfactory.Values, error) {
+		opts, err := handlers.BuildOptions(k8s, cluster, addon, adoc)
 		if err != nil {
 			return nil, err
 		}
@@ -132,6 +154,7 @@ func Test_Logging_AllConfigsTogether_AllResources(t *testing.T) {
 				{
 					Name: "app-logs",
 					Type: loggingv1.OutputTypeLoki,
+					URL:  "https://example.com",
 					OutputTypeSpec: loggingv1.OutputTypeSpec{
 						Loki: &loggingv1.Loki{
 							LabelKeys: []string{"key-1", "key-2"},
@@ -171,8 +194,8 @@ func Test_Logging_AllConfigsTogether_AllResources(t *testing.T) {
 			Namespace: "open-cluster-management",
 		},
 		Data: map[string][]byte{
-			"key":  []byte("data"),
-			"pass": []byte("data"),
+			"aws_access_key_id":     []byte("data"),
+			"aws_secret_access_key": []byte("data"),
 		},
 	}
 
@@ -237,6 +260,9 @@ func Test_Logging_AllConfigsTogether_AllResources(t *testing.T) {
 		switch obj := obj.(type) {
 		case *operatorsv1alpha1.Subscription:
 			require.Equal(t, obj.Spec.Channel, "stable-5.8")
+		case *loggingv1.ClusterLogging:
+			require.Equal(t, loggingv1.LogCollectionTypeVector, obj.Spec.Collection.Type)
+			require.Nil(t, obj.Spec.LogStore)
 		case *loggingv1.ClusterLogForwarder:
 			require.NotNil(t, obj.Spec.Outputs[0].Secret)
 			require.NotNil(t, obj.Spec.Outputs[1].Secret)
@@ -254,3 +280,499 @@ func Test_Logging_AllConfigsTogether_AllResources(t *testing.T) {
 		}
 	}
 }
+
+func Test_Logging_MultipleClusterLogForwarders(t *testing.T) {
+	managedCluster := addontesting.NewManagedCluster("cluster-1")
+
+	managedClusterAddOn := addontesting.NewAddon("test", "cluster-1")
+	managedClusterAddOn.Spec.Configs = []addonapiv1alpha1.AddOnConfig{
+		{
+			ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+				Group:    "",
+				Resource: "configmaps",
+			},
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{
+				Namespace: "open-cluster-management",
+				Name:      "logging-auth",
+			},
+		},
+	}
+	managedClusterAddOn.Status.ConfigReferences = []addonapiv1alpha1.ConfigReference{
+		{
+			ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+				Group:    "logging.openshift.io",
+				Resource: "clusterlogforwarders",
+			},
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{
+				Namespace: "open-cluster-management",
+				Name:      "instance",
+			},
+		},
+		{
+			ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+				Group:    "logging.openshift.io",
+				Resource: "clusterlogforwarders",
+			},
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{
+				Namespace: "open-cluster-management",
+				Name:      "audit",
+			},
+		},
+	}
+
+	forwarder := func(name, output string, input string) *loggingv1.ClusterLogForwarder {
+		return &loggingv1.ClusterLogForwarder{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "open-cluster-management",
+			},
+			Spec: loggingv1.ClusterLogForwarderSpec{
+				Outputs: []loggingv1.OutputSpec{
+					{
+						Name: output,
+						Type: loggingv1.OutputTypeLoki,
+						URL:  "https://example.com/{{ .ClusterName }}",
+					},
+				},
+				Pipelines: []loggingv1.PipelineSpec{
+					{
+						Name:       output,
+						InputRefs:  []string{input},
+						OutputRefs: []string{output},
+					},
+				},
+			},
+		}
+	}
+
+	staticCred := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "static-authentication",
+			Namespace: "open-cluster-management",
+		},
+		Data: map[string][]byte{
+			"username": []byte("user"),
+			"password": []byte("pass"),
+		},
+	}
+
+	authCM := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "logging-auth",
+			Namespace: "open-cluster-management",
+			Labels: map[string]string{
+				"mcoa.openshift.io/signal": "logging",
+			},
+		},
+		Data: map[string]string{
+			"app-logs":   "StaticAuthentication",
+			"audit-logs": "StaticAuthentication",
+		},
+	}
+
+	fakeKubeClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(
+			forwarder("instance", "app-logs", loggingv1.InputNameApplication),
+			forwarder("audit", "audit-logs", loggingv1.InputNameAudit),
+			staticCred,
+			authCM,
+		).
+		Build()
+
+	loggingAgentAddon, err := addonfactory.NewAgentAddonFactory(addon.Name, addon.FS, addon.LoggingChartDir).
+		WithGetValuesFuncs(fakeGetValues(fakeKubeClient)).
+		WithAgentRegistrationOption(&agent.RegistrationOption{}).
+		WithScheme(scheme.Scheme).
+		BuildHelmAgentAddon()
+	require.NoError(t, err)
+
+	// Namespace, OperatorGroup, Subscription, ClusterLogging, two
+	// ClusterLogForwarders, two Secrets and the ServiceAccount of the audit
+	// forwarder with its three ClusterRoleBindings
+	objects, err := loggingAgentAddon.Manifests(managedCluster, managedClusterAddOn)
+	require.NoError(t, err)
+	require.Equal(t, 12, len(objects))
+
+	forwarders := map[string]string{}
+	for _, obj := range objects {
+		switch obj := obj.(type) {
+		case *loggingv1.ClusterLogForwarder:
+			require.Equal(t, "openshift-logging", obj.Namespace)
+			require.Equal(t, "https://example.com/cluster-1", obj.Spec.Outputs[0].URL)
+			forwarders[obj.Name] = obj.Spec.ServiceAccountName
+		case *corev1.ServiceAccount:
+			require.Equal(t, "mcoa-logcollector-audit", obj.Name)
+		case *rbacv1.ClusterRoleBinding:
+			require.Equal(t, "mcoa-logcollector-audit", obj.Subjects[0].Name)
+		}
+	}
+	require.Equal(t, map[string]string{
+		"instance": "",
+		"audit":    "mcoa-logcollector-audit",
+	}, forwarders)
+}
+
+func Test_Logging_MultipleClusterLogForwarders_SameOutputName(t *testing.T) {
+	managedCluster := addontesting.NewManagedCluster("cluster-1")
+
+	managedClusterAddOn := addontesting.NewAddon("test", "cluster-1")
+	managedClusterAddOn.Spec.Configs = []addonapiv1alpha1.AddOnConfig{
+		{
+			ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+				Group:    "",
+				Resource: "configmaps",
+			},
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{
+				Namespace: "open-cluster-management",
+				Name:      "logging-auth",
+			},
+		},
+	}
+	managedClusterAddOn.Status.ConfigReferences = []addonapiv1alpha1.ConfigReference{
+		{
+			ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+				Group:    "logging.openshift.io",
+				Resource: "clusterlogforwarders",
+			},
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{
+				Namespace: "open-cluster-management",
+				Name:      "instance",
+			},
+		},
+		{
+			ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+				Group:    "logging.openshift.io",
+				Resource: "clusterlogforwarders",
+			},
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{
+				Namespace: "open-cluster-management",
+				Name:      "audit",
+			},
+		},
+	}
+
+	forwarder := func(name, input string) *loggingv1.ClusterLogForwarder {
+		return &loggingv1.ClusterLogForwarder{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "open-cluster-management",
+			},
+			Spec: loggingv1.ClusterLogForwarderSpec{
+				Outputs: []loggingv1.OutputSpec{
+					{
+						Name: "loki",
+						Type: loggingv1.OutputTypeLoki,
+						URL:  "https://example.com/" + name,
+					},
+				},
+				Pipelines: []loggingv1.PipelineSpec{
+					{
+						Name:       name,
+						InputRefs:  []string{input},
+						OutputRefs: []string{"loki"},
+					},
+				},
+			},
+		}
+	}
+
+	staticCred := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "static-authentication",
+			Namespace: "open-cluster-management",
+		},
+		Data: map[string][]byte{
+			"username": []byte("user"),
+			"password": []byte("pass"),
+		},
+	}
+
+	// Both forwarders have an output named loki, each with its own secret
+	authCM := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "logging-auth",
+			Namespace: "open-cluster-management",
+			Labels: map[string]string{
+				"mcoa.openshift.io/signal": "logging",
+			},
+		},
+		Data: map[string]string{
+			"instance.loki": "StaticAuthentication",
+			"audit.loki":    "StaticAuthentication",
+		},
+	}
+
+	fakeKubeClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(
+			forwarder("instance", loggingv1.InputNameApplication),
+			forwarder("audit", loggingv1.InputNameAudit),
+			staticCred,
+			authCM,
+		).
+		Build()
+
+	loggingAgentAddon, err := addonfactory.NewAgentAddonFactory(addon.Name, addon.FS, addon.LoggingChartDir).
+		WithGetValuesFuncs(fakeGetValues(fakeKubeClient)).
+		WithAgentRegistrationOption(&agent.RegistrationOption{}).
+		WithScheme(scheme.Scheme).
+		BuildHelmAgentAddon()
+	require.NoError(t, err)
+
+	objects, err := loggingAgentAddon.Manifests(managedCluster, managedClusterAddOn)
+	require.NoError(t, err)
+
+	secrets := map[string]string{}
+	var secretNames []string
+	for _, obj := range objects {
+		switch obj := obj.(type) {
+		case *loggingv1.ClusterLogForwarder:
+			require.NotNil(t, obj.Spec.Outputs[0].Secret)
+			secrets[obj.Name] = obj.Spec.Outputs[0].Secret.Name
+		case *corev1.Secret:
+			secretNames = append(secretNames, obj.Name)
+		}
+	}
+	require.Equal(t, map[string]string{
+		"instance": "logging-instance.loki-auth",
+		"audit":    "logging-audit.loki-auth",
+	}, secrets)
+	require.ElementsMatch(t, []string{"logging-instance.loki-auth", "logging-audit.loki-auth"}, secretNames)
+}
+
+func Test_Logging_ObservabilityAPI(t *testing.T) {
+	var (
+		// Addon envinronment and registration
+		managedCluster      *clusterv1.ManagedCluster
+		managedClusterAddOn *addonapiv1alpha1.ManagedClusterAddOn
+
+		// Addon configuration
+		addOnDeploymentConfig *addonapiv1alpha1.AddOnDeploymentConfig
+		clf                   *unstructured.Unstructured
+		authCM                *corev1.ConfigMap
+		staticCred            *corev1.Secret
+
+		// Test clients
+		fakeKubeClient  client.Client
+		fakeAddonClient *fakeaddon.Clientset
+	)
+
+	// Setup a managed cluster
+	managedCluster = addontesting.NewManagedCluster("cluster-1")
+
+	// Register the addon for the managed cluster
+	managedClusterAddOn = addontesting.NewAddon("test", "cluster-1")
+	managedClusterAddOn.Spec.Configs = []addonapiv1alpha1.AddOnConfig{
+		{
+			ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+				Group:    "",
+				Resource: "configmaps",
+			},
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{
+				Namespace: "open-cluster-management",
+				Name:      "logging-auth",
+			},
+		},
+	}
+	managedClusterAddOn.Status.ConfigReferences = []addonapiv1alpha1.ConfigReference{
+		{
+			ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+				Group:    "observability.openshift.io",
+				Resource: "clusterlogforwarders",
+			},
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{
+				Namespace: "open-cluster-management",
+				Name:      "instance",
+			},
+		},
+	}
+
+	// Setup configuration resources: ClusterLogForwarder, AddOnDeploymentConfig, Secrets, ConfigMaps
+	clf = &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"outputs": []interface{}{
+					map[string]interface{}{
+						"name": "app-logs",
+						"type": "loki",
+						"loki": map[string]interface{}{
+							"url": "https://example.com",
+						},
+					},
+				},
+				"pipelines": []interface{}{
+					map[string]interface{}{
+						"name":       "app-logs",
+						"inputRefs":  []interface{}{"application"},
+						"outputRefs": []interface{}{"app-logs"},
+					},
+				},
+			},
+		},
+	}
+	clf.SetGroupVersionKind(manifests.ObservabilityClusterLogForwarderGVK)
+	clf.SetName("instance")
+	clf.SetNamespace("open-cluster-management")
+
+	staticCred = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "static-authentication",
+			Namespace: "open-cluster-management",
+		},
+		Data: map[string][]byte{
+			"username": []byte("user"),
+			"password": []byte("pass"),
+		},
+	}
+
+	authCM = &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "logging-auth",
+			Namespace: "open-cluster-management",
+			Labels: map[string]string{
+				"mcoa.openshift.io/signal": "logging",
+			},
+		},
+		Data: map[string]string{
+			"app-logs": "StaticAuthentication",
+		},
+	}
+
+	addOnDeploymentConfig = &addonapiv1alpha1.AddOnDeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "multicluster-observability-addon",
+			Namespace: "open-cluster-management",
+		},
+		Spec: addonapiv1alpha1.AddOnDeploymentConfigSpec{
+			CustomizedVariables: []addonapiv1alpha1.CustomizedVariable{
+				{
+					Name:  "loggingSubscriptionChannel",
+					Value: "stable-6.0",
+				},
+			},
+		},
+	}
+
+	// Setup the fake k8s client
+	fakeKubeClient = fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(clf, staticCred, authCM).
+		Build()
+
+	// Setup the fake addon client
+	fakeAddonClient = fakeaddon.NewSimpleClientset(addOnDeploymentConfig)
+	addonConfigValuesFn := addonfactory.GetAddOnDeploymentConfigValues(
+		addonfactory.NewAddOnDeploymentConfigGetter(fakeAddonClient),
+		addonfactory.ToAddOnCustomizedVariableValues,
+	)
+
+	// Wire everything together to a fake addon instance
+	loggingAgentAddon, err := addonfactory.NewAgentAddonFactory(addon.Name, addon.FS, addon.LoggingChartDir).
+		WithGetValuesFuncs(addonConfigValuesFn, fakeGetValuesWithConfig(fakeKubeClient, addOnDeploymentConfig)).
+		WithAgentRegistrationOption(&agent.RegistrationOption{}).
+		WithScheme(addon.NewRenderScheme(scheme.Scheme, manifests.ObservabilityClusterLogForwarderGVK)).
+		BuildHelmAgentAddon()
+	if err != nil {
+		klog.Fatalf("failed to build agent %v", err)
+	}
+
+	// Render manifests and return them as k8s runtime objects
+	objects, err := loggingAgentAddon.Manifests(managedCluster, managedClusterAddOn)
+	require.NoError(t, err)
+	require.Equal(t, 9, len(objects))
+
+	var bindings int
+	for _, obj := range objects {
+		switch obj := obj.(type) {
+		case *loggingv1.ClusterLogging:
+			require.Fail(t, "ClusterLogging must not be rendered for Logging 6")
+		case *corev1.ServiceAccount:
+			require.Equal(t, "mcoa-logcollector", obj.Name)
+		case *rbacv1.ClusterRoleBinding:
+			require.Equal(t, "mcoa-logcollector", obj.Subjects[0].Name)
+			bindings++
+		case *unstructured.Unstructured:
+			require.Equal(t, manifests.ObservabilityClusterLogForwarderGVK, obj.GroupVersionKind())
+
+			sa, _, err := unstructured.NestedString(obj.Object, "spec", "serviceAccount", "name")
+			require.NoError(t, err)
+			require.Equal(t, "mcoa-logcollector", sa)
+
+			outputs, _, err := unstructured.NestedSlice(obj.Object, "spec", "outputs")
+			require.NoError(t, err)
+			username, _, err := unstructured.NestedStringMap(outputs[0].(map[string]interface{}), "loki", "authentication", "username")
+			require.NoError(t, err)
+			require.Equal(t, map[string]string{"secretName": "logging-app-logs-auth", "key": "username"}, username)
+		}
+	}
+	require.Equal(t, 3, bindings)
+}
+
+func Test_Logging_TrustBundleSecret(t *testing.T) {
+	managedCluster := addontesting.NewManagedCluster("cluster-1")
+	managedClusterAddOn := addontesting.NewAddon("test", "cluster-1")
+
+	opts := manifests.Options{
+		ClusterName: "cluster-1",
+		ClusterLogForwarders: []loggingv1.ClusterLogForwarder{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "instance"},
+				Spec: loggingv1.ClusterLogForwarderSpec{
+					Outputs: []loggingv1.OutputSpec{
+						{Name: "app-logs", Type: loggingv1.OutputTypeHttp, URL: "https://logs"},
+					},
+					Pipelines: []loggingv1.PipelineSpec{
+						{Name: "app", InputRefs: []string{loggingv1.InputNameApplication}, OutputRefs: []string{"app-logs"}},
+					},
+				},
+			},
+		},
+		Secrets: []corev1.Secret{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "logging-app-logs-auth",
+					Annotations: map[string]string{manifests.AnnotationTargetOutputName: "app-logs"},
+				},
+				Data: map[string][]byte{"tls.crt": []byte("cert"), "tls.key": []byte("key")},
+			},
+		},
+		CABundles: map[authentication.Target]string{"app-logs": "hub-ca"},
+	}
+
+	loggingAgentAddon, err := addonfactory.NewAgentAddonFactory(addon.Name, addon.FS, addon.LoggingChartDir).
+		WithGetValuesFuncs(func(_ *clusterv1.ManagedCluster, _ *addonapiv1alpha1.ManagedClusterAddOn) (addonfactory.Values, error) {
+			logging, err := manifests.BuildValues(opts)
+			if err != nil {
+				return nil, err
+			}
+			return addonfactory.JsonStructToValues(logging)
+		}).
+		WithAgentRegistrationOption(&agent.RegistrationOption{}).
+		WithScheme(scheme.Scheme).
+		BuildHelmAgentAddon()
+	require.NoError(t, err)
+
+	objects, err := loggingAgentAddon.Manifests(managedCluster, managedClusterAddOn)
+	require.NoError(t, err)
+
+	var secret *corev1.Secret
+	var trustBundle *corev1.ConfigMap
+	for _, obj := range objects {
+		switch obj := obj.(type) {
+		case *corev1.Secret:
+			secret = obj
+		case *corev1.ConfigMap:
+			trustBundle = obj
+		}
+	}
+	require.NotNil(t, trustBundle)
+	require.Equal(t, "hub-ca", trustBundle.Data["app-logs.crt"])
+	// The CA of the secret is rendered from the trust bundle
+	require.NotNil(t, secret)
+	require.Equal(t, map[string][]byte{
+		"tls.crt":       []byte("cert"),
+		"tls.key":       []byte("key"),
+		"ca-bundle.crt": []byte("hub-ca"),
+	}, secret.Data)
+}
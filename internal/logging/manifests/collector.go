@@ -0,0 +1,137 @@
+package manifests
+
+import (
+	"github.com/ViaQ/logerr/v2/kverrors"
+	loggingv1 "github.com/openshift/cluster-logging-operator/apis/logging/v1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// AddOnDeploymentConfig variables configuring the collector resources and the
+// log store of the spoke clusters
+const (
+	collectorCPURequestKey    = "loggingCollectorCPURequest"
+	collectorMemoryRequestKey = "loggingCollectorMemoryRequest"
+	collectorCPULimitKey      = "loggingCollectorCPULimit"
+	collectorMemoryLimitKey   = "loggingCollectorMemoryLimit"
+	logStoreLokiStackKey      = "loggingLogStoreLokiStack"
+)
+
+// buildCollectorSpec returns the collector settings of the cluster. The node
+// placement of the AddOnDeploymentConfig sets the collector nodeSelector and
+// tolerations and the customized variables its resources.
+func buildCollectorSpec(resources Options) (loggingv1.CollectorSpec, error) {
+	collector := loggingv1.CollectorSpec{}
+
+	adoc := resources.AddOnDeploymentConfig
+	if adoc == nil {
+		return collector, nil
+	}
+
+	if placement := adoc.Spec.NodePlacement; placement != nil {
+		collector.NodeSelector = placement.NodeSelector
+		collector.Tolerations = placement.Tolerations
+	}
+
+	requests, err := resourceList(adoc, map[string]corev1.ResourceName{
+		collectorCPURequestKey:    corev1.ResourceCPU,
+		collectorMemoryRequestKey: corev1.ResourceMemory,
+	})
+	if err != nil {
+		return collector, err
+	}
+
+	limits, err := resourceList(adoc, map[string]corev1.ResourceName{
+		collectorCPULimitKey:    corev1.ResourceCPU,
+		collectorMemoryLimitKey: corev1.ResourceMemory,
+	})
+	if err != nil {
+		return collector, err
+	}
+
+	requirements := corev1.ResourceRequirements{
+		Requests: requests,
+		Limits:   limits,
+	}
+
+	if requirements.Requests != nil || requirements.Limits != nil {
+		collector.Resources = &requirements
+	}
+
+	return collector, nil
+}
+
+func resourceList(adoc *addonapiv1alpha1.AddOnDeploymentConfig, variables map[string]corev1.ResourceName) (corev1.ResourceList, error) {
+	var list corev1.ResourceList
+	for key, name := range variables {
+		value := addon.CustomizedVariable(adoc, key)
+		if value == "" {
+			continue
+		}
+
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, kverrors.Wrap(err, "invalid collector resource quantity", "variable", key, "value", value)
+		}
+
+		if list == nil {
+			list = corev1.ResourceList{}
+		}
+		list[name] = quantity
+	}
+	return list, nil
+}
+
+// buildClusterLoggingSpec returns the spec of the ClusterLogging instance. The
+// collector is always vector and no log store is deployed unless a LokiStack
+// running on the spoke is configured.
+func buildClusterLoggingSpec(resources Options) (*loggingv1.ClusterLoggingSpec, error) {
+	collector, err := buildCollectorSpec(resources)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &loggingv1.ClusterLoggingSpec{
+		ManagementState: loggingv1.ManagementStateManaged,
+		Collection: &loggingv1.CollectionSpec{
+			Type:          loggingv1.LogCollectionTypeVector,
+			CollectorSpec: collector,
+		},
+	}
+
+	if name := addon.CustomizedVariable(resources.AddOnDeploymentConfig, logStoreLokiStackKey); name != "" {
+		spec.LogStore = &loggingv1.LogStoreSpec{
+			Type: loggingv1.LogStoreTypeLokiStack,
+			LokiStack: loggingv1.LokiStackStoreSpec{
+				Name: name,
+			},
+		}
+	}
+
+	return spec, nil
+}
+
+// configureObservabilityCollector sets the collector settings of the cluster
+// in a observability.openshift.io/v1 ClusterLogForwarder spec, this API
+// replaced the ClusterLogging collection settings.
+func configureObservabilityCollector(spec map[string]interface{}, resources Options) error {
+	collector, err := buildCollectorSpec(resources)
+	if err != nil {
+		return err
+	}
+
+	if collector.Resources == nil && collector.NodeSelector == nil && collector.Tolerations == nil {
+		return nil
+	}
+
+	collectorMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&collector)
+	if err != nil {
+		return kverrors.Wrap(err, "failed to convert collector settings")
+	}
+	spec["collector"] = collectorMap
+
+	return nil
+}
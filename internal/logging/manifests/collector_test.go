@@ -0,0 +1,120 @@
+package manifests
+
+import (
+	"testing"
+
+	loggingv1 "github.com/openshift/cluster-logging-operator/apis/logging/v1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+func Test_BuildClusterLoggingSpec(t *testing.T) {
+	tolerations := []corev1.Toleration{
+		{
+			Key:      "node-role.kubernetes.io/infra",
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		},
+	}
+
+	for _, tc := range []struct {
+		name    string
+		adoc    *addonapiv1alpha1.AddOnDeploymentConfig
+		want    *loggingv1.ClusterLoggingSpec
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			want: &loggingv1.ClusterLoggingSpec{
+				ManagementState: loggingv1.ManagementStateManaged,
+				Collection: &loggingv1.CollectionSpec{
+					Type: loggingv1.LogCollectionTypeVector,
+				},
+			},
+		},
+		{
+			name: "collector settings and log store",
+			adoc: &addonapiv1alpha1.AddOnDeploymentConfig{
+				Spec: addonapiv1alpha1.AddOnDeploymentConfigSpec{
+					NodePlacement: &addonapiv1alpha1.NodePlacement{
+						NodeSelector: map[string]string{"node-role.kubernetes.io/infra": ""},
+						Tolerations:  tolerations,
+					},
+					CustomizedVariables: []addonapiv1alpha1.CustomizedVariable{
+						{Name: "loggingCollectorCPURequest", Value: "100m"},
+						{Name: "loggingCollectorMemoryLimit", Value: "1Gi"},
+						{Name: "loggingLogStoreLokiStack", Value: "logging-loki"},
+					},
+				},
+			},
+			want: &loggingv1.ClusterLoggingSpec{
+				ManagementState: loggingv1.ManagementStateManaged,
+				Collection: &loggingv1.CollectionSpec{
+					Type: loggingv1.LogCollectionTypeVector,
+					CollectorSpec: loggingv1.CollectorSpec{
+						NodeSelector: map[string]string{"node-role.kubernetes.io/infra": ""},
+						Tolerations:  tolerations,
+						Resources: &corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU: resource.MustParse("100m"),
+							},
+							Limits: corev1.ResourceList{
+								corev1.ResourceMemory: resource.MustParse("1Gi"),
+							},
+						},
+					},
+				},
+				LogStore: &loggingv1.LogStoreSpec{
+					Type: loggingv1.LogStoreTypeLokiStack,
+					LokiStack: loggingv1.LokiStackStoreSpec{
+						Name: "logging-loki",
+					},
+				},
+			},
+		},
+		{
+			name: "invalid quantity",
+			adoc: &addonapiv1alpha1.AddOnDeploymentConfig{
+				Spec: addonapiv1alpha1.AddOnDeploymentConfigSpec{
+					CustomizedVariables: []addonapiv1alpha1.CustomizedVariable{
+						{Name: "loggingCollectorCPULimit", Value: "one"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec, err := buildClusterLoggingSpec(Options{AddOnDeploymentConfig: tc.adoc})
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, spec)
+		})
+	}
+}
+
+func Test_ConfigureObservabilityCollector(t *testing.T) {
+	resources := Options{
+		AddOnDeploymentConfig: &addonapiv1alpha1.AddOnDeploymentConfig{
+			Spec: addonapiv1alpha1.AddOnDeploymentConfigSpec{
+				NodePlacement: &addonapiv1alpha1.NodePlacement{
+					NodeSelector: map[string]string{"infra": "true"},
+				},
+			},
+		},
+	}
+
+	spec := map[string]interface{}{}
+	err := configureObservabilityCollector(spec, resources)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"collector": map[string]interface{}{
+			"nodeSelector": map[string]interface{}{"infra": "true"},
+		},
+	}, spec)
+}
@@ -0,0 +1,107 @@
+package manifests
+
+import (
+	"regexp"
+
+	loggingv1 "github.com/openshift/cluster-logging-operator/apis/logging/v1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// AddOnDeploymentConfig variable listing the ManagedCluster labels, comma
+	// separated, added to the forwarded logs
+	clusterLabelsKey = "loggingClusterLabels"
+
+	clusterIDClaim = "id.openshift.io"
+
+	clusterNameLabel = "cluster_name"
+	clusterIDLabel   = "cluster_id"
+
+	clusterLabelsFilterName = "mcoa-cluster-labels"
+	openshiftLabelsFilter   = "openshiftLabels"
+)
+
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// buildClusterLabels returns the labels identifying the cluster that are
+// added to every log record: the cluster name, the cluster ID claimed by the
+// cluster and the ManagedCluster labels selected in the AddOnDeploymentConfig.
+// Label keys are sanitized to be valid record field names.
+func buildClusterLabels(resources Options) map[string]string {
+	labels := map[string]string{
+		clusterNameLabel: resources.ClusterName,
+	}
+
+	cluster := resources.ManagedCluster
+	if cluster == nil {
+		return labels
+	}
+
+	for _, claim := range cluster.Status.ClusterClaims {
+		if claim.Name == clusterIDClaim {
+			labels[clusterIDLabel] = claim.Value
+		}
+	}
+
+	for _, key := range splitList(addon.CustomizedVariable(resources.AddOnDeploymentConfig, clusterLabelsKey)) {
+		if value, ok := cluster.Labels[key]; ok {
+			labels[invalidLabelChars.ReplaceAllString(key, "_")] = value
+		}
+	}
+
+	return labels
+}
+
+// configureClusterLabels adds the cluster labels to every pipeline of the
+// spec, labels already set in the template with the same keys are replaced.
+func configureClusterLabels(spec *loggingv1.ClusterLogForwarderSpec, resources Options) {
+	labels := buildClusterLabels(resources)
+	for i := range spec.Pipelines {
+		if spec.Pipelines[i].Labels == nil {
+			spec.Pipelines[i].Labels = map[string]string{}
+		}
+		for k, v := range labels {
+			spec.Pipelines[i].Labels[k] = v
+		}
+	}
+}
+
+// configureObservabilityClusterLabels is the equivalent of
+// configureClusterLabels for the observability.openshift.io/v1 API where the
+// labels are added by an openshiftLabels filter referenced by every pipeline.
+func configureObservabilityClusterLabels(spec map[string]interface{}, resources Options) error {
+	pipelines, _, err := unstructured.NestedSlice(spec, "pipelines")
+	if err != nil || len(pipelines) == 0 {
+		return err
+	}
+
+	labels := map[string]interface{}{}
+	for k, v := range buildClusterLabels(resources) {
+		labels[k] = v
+	}
+
+	filters, _, err := unstructured.NestedSlice(spec, "filters")
+	if err != nil {
+		return err
+	}
+	filters = append(filters, map[string]interface{}{
+		"name":                clusterLabelsFilterName,
+		"type":                openshiftLabelsFilter,
+		openshiftLabelsFilter: labels,
+	})
+	if err := unstructured.SetNestedSlice(spec, filters, "filters"); err != nil {
+		return err
+	}
+
+	for _, p := range pipelines {
+		pipeline, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		refs, _, _ := unstructured.NestedSlice(pipeline, "filterRefs")
+		pipeline["filterRefs"] = append(refs, clusterLabelsFilterName)
+	}
+
+	return unstructured.SetNestedSlice(spec, pipelines, "pipelines")
+}
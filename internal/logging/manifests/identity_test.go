@@ -0,0 +1,104 @@
+package manifests
+
+import (
+	"testing"
+
+	loggingv1 "github.com/openshift/cluster-logging-operator/apis/logging/v1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+func clusterIdentityOptions() Options {
+	return Options{
+		ClusterName: "cluster-1",
+		ManagedCluster: &clusterv1.ManagedCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "cluster-1",
+				Labels: map[string]string{
+					"region":                  "eu-west-1",
+					"example.com/environment": "prod",
+					"team":                    "ignored",
+				},
+			},
+			Status: clusterv1.ManagedClusterStatus{
+				ClusterClaims: []clusterv1.ManagedClusterClaim{
+					{Name: "id.openshift.io", Value: "4f7e6b1c"},
+					{Name: "platform.open-cluster-management.io", Value: "AWS"},
+				},
+			},
+		},
+		AddOnDeploymentConfig: &addonapiv1alpha1.AddOnDeploymentConfig{
+			Spec: addonapiv1alpha1.AddOnDeploymentConfigSpec{
+				CustomizedVariables: []addonapiv1alpha1.CustomizedVariable{
+					{Name: "loggingClusterLabels", Value: "region, example.com/environment, missing"},
+				},
+			},
+		},
+	}
+}
+
+func Test_ConfigureClusterLabels(t *testing.T) {
+	spec := &loggingv1.ClusterLogForwarderSpec{
+		Pipelines: []loggingv1.PipelineSpec{
+			{
+				Name: "app",
+			},
+			{
+				Name: "infra",
+				Labels: map[string]string{
+					"cluster_name": "wrong",
+					"team":         "infra",
+				},
+			},
+		},
+	}
+
+	configureClusterLabels(spec, clusterIdentityOptions())
+
+	want := map[string]string{
+		"cluster_name":            "cluster-1",
+		"cluster_id":              "4f7e6b1c",
+		"region":                  "eu-west-1",
+		"example_com_environment": "prod",
+	}
+	require.Equal(t, want, spec.Pipelines[0].Labels)
+
+	want["team"] = "infra"
+	require.Equal(t, want, spec.Pipelines[1].Labels)
+}
+
+func Test_ConfigureObservabilityClusterLabels(t *testing.T) {
+	spec := map[string]interface{}{
+		"pipelines": []interface{}{
+			map[string]interface{}{
+				"name":       "app",
+				"filterRefs": []interface{}{"drop-debug"},
+			},
+		},
+	}
+
+	err := configureObservabilityClusterLabels(spec, clusterIdentityOptions())
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"filters": []interface{}{
+			map[string]interface{}{
+				"name": "mcoa-cluster-labels",
+				"type": "openshiftLabels",
+				"openshiftLabels": map[string]interface{}{
+					"cluster_name":            "cluster-1",
+					"cluster_id":              "4f7e6b1c",
+					"region":                  "eu-west-1",
+					"example_com_environment": "prod",
+				},
+			},
+		},
+		"pipelines": []interface{}{
+			map[string]interface{}{
+				"name":       "app",
+				"filterRefs": []interface{}{"drop-debug", "mcoa-cluster-labels"},
+			},
+		},
+	}, spec)
+}
@@ -2,8 +2,13 @@ package manifests
 
 import (
 	"encoding/json"
+	"fmt"
 
+	"github.com/ViaQ/logerr/v2/kverrors"
 	loggingv1 "github.com/openshift/cluster-logging-operator/apis/logging/v1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/patch"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -24,67 +29,147 @@ func buildSubscriptionChannel(resources Options) string {
 func buildSecrets(resources Options) ([]SecretValue, error) {
 	secretsValue := []SecretValue{}
 	for _, secret := range resources.Secrets {
+		secretValue := SecretValue{
+			Name: secret.Name,
+		}
+
+		// logging.openshift.io/v1 outputs can only read the CA from their
+		// secret, the chart renders it from the trust bundle
+		target := authentication.Target(secret.Annotations[AnnotationTargetOutputName])
+		if _, ok := resources.CABundles[target]; ok && len(resources.ObservabilityClusterLogForwarders) == 0 {
+			secret = *secret.DeepCopy()
+			delete(secret.Data, caBundleSecretKey)
+			secretValue.TrustBundleKey = authentication.TrustBundleKey(target)
+		}
+
 		dataJSON, err := json.Marshal(secret.Data)
 		if err != nil {
 			return secretsValue, err
 		}
-		secretValue := SecretValue{
-			Name: secret.Name,
-			Data: string(dataJSON),
-		}
+		secretValue.Data = string(dataJSON)
 		secretsValue = append(secretsValue, secretValue)
 	}
 	return secretsValue, nil
 }
 
-func buildClusterLogForwarderSpec(resources Options) (*loggingv1.ClusterLogForwarderSpec, error) {
-	clf := resources.ClusterLogForwarder
-	for _, secret := range resources.Secrets {
-		if err := templateWithSecret(&clf.Spec, secret); err != nil {
-			return nil, err
-		}
+func buildClusterLogForwarderSpec(resources Options, clf *loggingv1.ClusterLogForwarder) (*loggingv1.ClusterLogForwarderSpec, error) {
+	// Forwarders other than the default one are run by their own collector
+	if clf.Name != defaultForwarderName {
+		clf.Spec.ServiceAccountName = collectorServiceAccount(clf.Name)
 	}
 
+	templateWithSecrets(&clf.Spec, clf.Name, resources.Secrets)
+
+	values := addon.NewClusterValues(resources.ClusterName, resources.ManagedCluster)
 	for _, configmap := range resources.ConfigMaps {
-		if err := templateWithConfigMap(&clf.Spec, configmap); err != nil {
+		if err := templateWithConfigMap(&clf.Spec, configmap, values); err != nil {
 			return nil, err
 		}
 	}
 
-	return &clf.Spec, nil
+	configureClusterLabels(&clf.Spec, resources)
+
+	return applyPatches(&clf.Spec, clf.Name, resources.Patches)
 }
 
-func templateWithSecret(spec *loggingv1.ClusterLogForwarderSpec, secret corev1.Secret) error {
-	clfOutputName, ok := secret.Annotations[AnnotationTargetOutputName]
-	if !ok {
-		return nil
+// applyPatches applies the patches targeting the forwarder to its templated
+// spec.
+func applyPatches(spec *loggingv1.ClusterLogForwarderSpec, name string, patches []patch.Patch) (*loggingv1.ClusterLogForwarderSpec, error) {
+	if len(patches) == 0 {
+		return spec, nil
+	}
+
+	doc, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err = patch.Apply(doc, name, patches, loggingv1.ClusterLogForwarderSpec{})
+	if err != nil {
+		return nil, err
 	}
-	// TODO(JoaoBraveCoding) Validate that clfOutputName actually exists
-	// TODO(JoaoBraveCoding) Validate secret
 
+	patched := &loggingv1.ClusterLogForwarderSpec{}
+	if err := json.Unmarshal(doc, patched); err != nil {
+		return nil, kverrors.Wrap(err, "patched ClusterLogForwarder spec is invalid", "name", name)
+	}
+	return patched, nil
+}
+
+// collectorServiceAccount returns the name of the ServiceAccount running the
+// collector of a forwarder.
+func collectorServiceAccount(forwarder string) string {
+	if forwarder == defaultForwarderName {
+		return collectorServiceAccountName
+	}
+	return fmt.Sprintf("%s-%s", collectorServiceAccountName, forwarder)
+}
+
+// templateWithSecrets references the secret of each output of the forwarder.
+func templateWithSecrets(spec *loggingv1.ClusterLogForwarderSpec, forwarder string, secrets []corev1.Secret) {
 	for k, output := range spec.Outputs {
-		if output.Name == clfOutputName {
-			output.Secret = &loggingv1.OutputSecretSpec{
-				Name: secret.Name,
-			}
-			spec.Outputs[k] = output
+		secret, ok := outputSecret(secrets, forwarder, output.Name)
+		if !ok {
+			continue
+		}
+		output.Secret = &loggingv1.OutputSecretSpec{
+			Name: secret.Name,
 		}
+		spec.Outputs[k] = output
 	}
+}
 
-	return nil
+// forwarderOutputTarget returns the target configuring the output of a single
+// forwarder, the targets named after the output configure it in all the
+// forwarders.
+func forwarderOutputTarget(forwarder, output string) authentication.Target {
+	return authentication.Target(fmt.Sprintf("%s.%s", forwarder, output))
 }
 
-func templateWithConfigMap(spec *loggingv1.ClusterLogForwarderSpec, configmap corev1.ConfigMap) error {
+// outputSecret returns the secret of the output of a forwarder. The secret of
+// the forwarder target takes precedence over the one of the output target.
+func outputSecret(secrets []corev1.Secret, forwarder, output string) (corev1.Secret, bool) {
+	var found *corev1.Secret
+	for i, secret := range secrets {
+		switch authentication.Target(secret.Annotations[AnnotationTargetOutputName]) {
+		case forwarderOutputTarget(forwarder, output):
+			return secrets[i], true
+		case authentication.Target(output):
+			found = &secrets[i]
+		}
+	}
+	if found == nil {
+		return corev1.Secret{}, false
+	}
+	return *found, true
+}
+
+// outputTarget returns the target of the secret of the output of a forwarder,
+// or the output name when it has no secret.
+func outputTarget(secrets []corev1.Secret, forwarder, output string) authentication.Target {
+	if secret, ok := outputSecret(secrets, forwarder, output); ok {
+		return authentication.Target(secret.Annotations[AnnotationTargetOutputName])
+	}
+	return authentication.Target(output)
+}
+
+func templateWithConfigMap(spec *loggingv1.ClusterLogForwarderSpec, configmap corev1.ConfigMap, values addon.ClusterValues) error {
 	clfOutputName, ok := configmap.Annotations[AnnotationTargetOutputName]
 	if !ok {
 		return nil
 	}
 
 	for k, output := range spec.Outputs {
-		if output.Name == clfOutputName && output.Type == "loki" {
-			output.URL = configmap.Data["url"]
-			spec.Outputs[k] = output
+		if output.Name != clfOutputName {
+			continue
+		}
+		if err := configureOutput(&output, configmap); err != nil {
+			return err
+		}
+		if err := configureTenant(&output, configmap, values); err != nil {
+			return err
 		}
+		spec.Outputs[k] = output
 	}
 
 	return nil
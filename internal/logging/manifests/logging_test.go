@@ -6,10 +6,14 @@ import (
 	"testing"
 
 	loggingv1 "github.com/openshift/cluster-logging-operator/apis/logging/v1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/patch"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/utils/pointer"
 	"open-cluster-management.io/addon-framework/pkg/addonmanager/addontesting"
 	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
@@ -96,6 +100,60 @@ func Test_BuildSecrets(t *testing.T) {
 	require.Equal(t, resources.Secrets[1].Data, *gotData)
 }
 
+func Test_BuildSecrets_TrustBundle(t *testing.T) {
+	resources := Options{
+		Secrets: []corev1.Secret{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "cluster-1",
+					Annotations: map[string]string{
+						AnnotationTargetOutputName: "app-logs",
+					},
+				},
+				Data: map[string][]byte{
+					"tls.crt":       []byte("cert"),
+					"ca-bundle.crt": []byte("stale"),
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "bar",
+					Namespace: "cluster-1",
+					Annotations: map[string]string{
+						AnnotationTargetOutputName: "cluster-logs",
+					},
+				},
+				Data: map[string][]byte{
+					"pass": []byte("bar-pass"),
+				},
+			},
+		},
+		CABundles: authentication.CABundles{
+			"app-logs": "ca",
+		},
+	}
+	secretsValue, err := buildSecrets(resources)
+	require.NoError(t, err)
+
+	// logging.openshift.io/v1 outputs get their CA from the trust bundle
+	require.Equal(t, "app-logs.crt", secretsValue[0].TrustBundleKey)
+	gotData := map[string][]byte{}
+	err = json.Unmarshal([]byte(secretsValue[0].Data), &gotData)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"tls.crt": []byte("cert")}, gotData)
+	// The secret from the hub should not be mutated
+	require.Contains(t, resources.Secrets[0].Data, "ca-bundle.crt")
+
+	require.Empty(t, secretsValue[1].TrustBundleKey)
+
+	// observability.openshift.io/v1 outputs reference the trust bundle
+	resources.ObservabilityClusterLogForwarders = []unstructured.Unstructured{{}}
+	secretsValue, err = buildSecrets(resources)
+	require.NoError(t, err)
+	require.Empty(t, secretsValue[0].TrustBundleKey)
+}
+
 func Test_BuildCLFSpec(t *testing.T) {
 	var (
 		// Addon envinronment and registration
@@ -235,57 +293,109 @@ func Test_BuildCLFSpec(t *testing.T) {
 
 	// Setup the fake k8s client
 	resources := Options{
-		ClusterLogForwarder: clf,
 		Secrets: []corev1.Secret{
 			*appLogsSecret,
 			*clusterLogsSecret,
 		},
 	}
-	clfSpec, err := buildClusterLogForwarderSpec(resources)
+	clfSpec, err := buildClusterLogForwarderSpec(resources, clf)
 	require.NoError(t, err)
+	require.Empty(t, clfSpec.ServiceAccountName)
 	require.NotNil(t, clfSpec.Outputs[0].Secret)
 	require.NotNil(t, clfSpec.Outputs[1].Secret)
 	require.Equal(t, appLogsSecret.Name, clfSpec.Outputs[0].Secret.Name)
 	require.Equal(t, clusterLogsSecret.Name, clfSpec.Outputs[1].Secret.Name)
 }
 
-func Test_TemplateWithSecret(t *testing.T) {
+func Test_BuildCLFSpec_Patches(t *testing.T) {
+	clf := &loggingv1.ClusterLogForwarder{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "instance",
+		},
+		Spec: loggingv1.ClusterLogForwarderSpec{
+			Outputs: []loggingv1.OutputSpec{
+				{
+					Name: "loki",
+					Type: loggingv1.OutputTypeLoki,
+					URL:  "https://loki",
+				},
+			},
+			Pipelines: []loggingv1.PipelineSpec{
+				{
+					Name:       "app",
+					InputRefs:  []string{loggingv1.InputNameApplication},
+					OutputRefs: []string{"loki"},
+				},
+			},
+		},
+	}
+
+	resources := Options{
+		Patches: []patch.Patch{
+			{
+				ConfigMap: "audit-pipeline",
+				Type:      patch.TypeJSON,
+				Data: map[string]string{
+					"instance": `[{"op": "add", "path": "/pipelines/-", "value": {"name": "audit", "inputRefs": ["audit"], "outputRefs": ["loki"]}}]`,
+				},
+			},
+		},
+	}
+
+	spec, err := buildClusterLogForwarderSpec(resources, clf)
+	require.NoError(t, err)
+	require.Len(t, spec.Pipelines, 2)
+	require.Equal(t, loggingv1.PipelineSpec{
+		Name:       "audit",
+		InputRefs:  []string{loggingv1.InputNameAudit},
+		OutputRefs: []string{"loki"},
+	}, spec.Pipelines[1])
+	require.Equal(t, "https://loki", spec.Outputs[0].URL)
+}
+
+func Test_TemplateWithSecrets(t *testing.T) {
+	secret := func(name, target string) corev1.Secret {
+		return corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "cluster-1",
+				Annotations: map[string]string{
+					"logging.mcoa.openshift.io/target-output-name": target,
+				},
+			},
+		}
+	}
+
 	for _, tc := range []struct {
-		name                       string
-		wrongTargetAnnotationValue bool
-		secretName                 string
-		expectedSecretName         string
+		name               string
+		secrets            []corev1.Secret
+		expectedSecretName string
 	}{
 		{
-			name:                       "SignalAnnotationSet",
-			wrongTargetAnnotationValue: false,
-			secretName:                 "my-secret",
-			expectedSecretName:         "my-secret",
+			name:               "OutputTarget",
+			secrets:            []corev1.Secret{secret("my-secret", "foo")},
+			expectedSecretName: "my-secret",
 		},
 		{
-			name:                       "SignalAnnotationNotSet",
-			wrongTargetAnnotationValue: true,
-			secretName:                 "my-secret",
-			expectedSecretName:         "",
+			name:               "OtherOutputTarget",
+			secrets:            []corev1.Secret{secret("my-secret", "bar")},
+			expectedSecretName: "",
+		},
+		{
+			name: "ForwarderTarget",
+			secrets: []corev1.Secret{
+				secret("forwarder-secret", "instance.foo"),
+				secret("my-secret", "foo"),
+			},
+			expectedSecretName: "forwarder-secret",
+		},
+		{
+			name:               "OtherForwarderTarget",
+			secrets:            []corev1.Secret{secret("forwarder-secret", "audit.foo")},
+			expectedSecretName: "",
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			secret := &corev1.Secret{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      tc.secretName,
-					Namespace: "cluster-1",
-					Annotations: map[string]string{
-						"logging.mcoa.openshift.io/target-output-name": "foo",
-					},
-				},
-			}
-
-			if tc.wrongTargetAnnotationValue {
-				secret.Annotations = map[string]string{
-					"logging.mcoa.openshift.io/target-output-name": "bar",
-				}
-			}
-
 			spec := &loggingv1.ClusterLogForwarderSpec{
 				Outputs: []loggingv1.OutputSpec{
 					{
@@ -294,9 +404,8 @@ func Test_TemplateWithSecret(t *testing.T) {
 				},
 			}
 
-			err := templateWithSecret(spec, *secret)
-			assert.NoError(t, err)
-			if tc.wrongTargetAnnotationValue {
+			templateWithSecrets(spec, "instance", tc.secrets)
+			if tc.expectedSecretName == "" {
 				assert.Nil(t, spec.Outputs[0].Secret)
 			} else {
 				assert.NotNil(t, spec.Outputs[0].Secret)
@@ -355,7 +464,7 @@ func Test_TemplateWithConfigMap(t *testing.T) {
 				},
 			}
 
-			err := templateWithConfigMap(spec, *cm)
+			err := templateWithConfigMap(spec, *cm, addon.NewClusterValues("cluster-1", nil))
 			assert.NoError(t, err, "Expected no error")
 			assert.Equal(t, tc.expectedCLFUrl, spec.Outputs[0].URL)
 		})
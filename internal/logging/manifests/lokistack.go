@@ -0,0 +1,95 @@
+package manifests
+
+import (
+	"fmt"
+
+	loggingv1 "github.com/openshift/cluster-logging-operator/apis/logging/v1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// LokiStackGateway describes the LokiStack gateway discovered on the hub that
+// receives the logs of the spoke clusters.
+type LokiStackGateway struct {
+	// URL of the gateway logs API without the tenant, e.g. https://<host>/api/logs/v1
+	URL string
+	// CA is the PEM encoded CA bundle that signed the gateway certificate
+	CA string
+}
+
+// configureLokiStackGateway points the Loki outputs of the template that don't
+// have an URL to the tenant of the cluster in the LokiStack gateway and adds
+// the gateway CA to their bundles. Outputs configured with a ConfigMap keep
+// the values of the ConfigMap. logging.openshift.io/v1 outputs can only read
+// the CA from their secret, a ValidationError is returned for the ones without
+// a secret since the gateway also requires them to authenticate.
+func configureLokiStackGateway(resources *Options) error {
+	gateway := resources.LokiStackGateway
+	if gateway == nil {
+		return nil
+	}
+
+	url := appendPath(gateway.URL, resources.ClusterName)
+	targets := []authentication.Target{}
+
+	var findings []string
+	for f := range resources.ClusterLogForwarders {
+		clf := &resources.ClusterLogForwarders[f]
+		for i, output := range clf.Spec.Outputs {
+			if output.Type != loggingv1.OutputTypeLoki || output.URL != "" {
+				continue
+			}
+			secret, ok := outputSecret(resources.Secrets, clf.Name, output.Name)
+			if !ok {
+				findings = append(findings, fmt.Sprintf("output %q sends logs to the LokiStack gateway and requires a secret", output.Name))
+				continue
+			}
+			clf.Spec.Outputs[i].URL = url
+			targets = append(targets, authentication.Target(secret.Annotations[AnnotationTargetOutputName]))
+		}
+	}
+	if len(findings) > 0 {
+		return newValidationError(findings)
+	}
+
+	for f := range resources.ObservabilityClusterLogForwarders {
+		clf := &resources.ObservabilityClusterLogForwarders[f]
+		outputs, _, err := unstructured.NestedSlice(clf.Object, "spec", "outputs")
+		if err != nil {
+			return err
+		}
+		for _, o := range outputs {
+			output, ok := o.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if output["type"] != observabilityOutputTypeLoki {
+				continue
+			}
+			if current, _, _ := unstructured.NestedString(output, observabilityOutputTypeLoki, "url"); current != "" {
+				continue
+			}
+			if err := unstructured.SetNestedField(output, url, observabilityOutputTypeLoki, "url"); err != nil {
+				return err
+			}
+			name, _, _ := unstructured.NestedString(output, "name")
+			targets = append(targets, outputTarget(resources.Secrets, clf.GetName(), name))
+		}
+		if err := unstructured.SetNestedSlice(clf.Object, outputs, "spec", "outputs"); err != nil {
+			return err
+		}
+	}
+
+	if gateway.CA == "" || len(targets) == 0 {
+		return nil
+	}
+
+	bundles := authentication.CABundles{}
+	for target, ca := range resources.CABundles {
+		bundles[target] = ca
+	}
+	bundles.Add(gateway.CA, targets...)
+	resources.CABundles = bundles
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package manifests
+
+import (
+	"testing"
+
+	loggingv1 "github.com/openshift/cluster-logging-operator/apis/logging/v1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_ConfigureLokiStackGateway(t *testing.T) {
+	resources := Options{
+		ClusterName: "cluster-1",
+		ClusterLogForwarders: []loggingv1.ClusterLogForwarder{
+			{
+				Spec: loggingv1.ClusterLogForwarderSpec{
+					Outputs: []loggingv1.OutputSpec{
+						{
+							Name: "discovered",
+							Type: loggingv1.OutputTypeLoki,
+						},
+						{
+							Name: "configured",
+							Type: loggingv1.OutputTypeLoki,
+							URL:  "https://other-loki",
+						},
+						{
+							Name: "cloudwatch",
+							Type: loggingv1.OutputTypeCloudwatch,
+						},
+					},
+				},
+			},
+		},
+		Secrets: []corev1.Secret{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "logging-discovered-auth",
+					Annotations: map[string]string{AnnotationTargetOutputName: "discovered"},
+				},
+			},
+		},
+		CABundles: authentication.CABundles{
+			"configured": "other-ca",
+		},
+		LokiStackGateway: &LokiStackGateway{
+			URL: "https://gateway/api/logs/v1",
+			CA:  "gateway-ca",
+		},
+	}
+
+	err := configureLokiStackGateway(&resources)
+	require.NoError(t, err)
+
+	outputs := resources.ClusterLogForwarders[0].Spec.Outputs
+	require.Equal(t, "https://gateway/api/logs/v1/cluster-1", outputs[0].URL)
+	require.Equal(t, "https://other-loki", outputs[1].URL)
+	require.Empty(t, outputs[2].URL)
+
+	require.Equal(t, authentication.CABundles{
+		"discovered": "gateway-ca",
+		"configured": "other-ca",
+	}, resources.CABundles)
+}
+
+func Test_ConfigureLokiStackGateway_NotDiscovered(t *testing.T) {
+	resources := Options{
+		ClusterLogForwarders: []loggingv1.ClusterLogForwarder{
+			{
+				Spec: loggingv1.ClusterLogForwarderSpec{
+					Outputs: []loggingv1.OutputSpec{
+						{
+							Name: "loki",
+							Type: loggingv1.OutputTypeLoki,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err := configureLokiStackGateway(&resources)
+	require.NoError(t, err)
+	require.Empty(t, resources.ClusterLogForwarders[0].Spec.Outputs[0].URL)
+	require.Nil(t, resources.CABundles)
+}
+
+func Test_ConfigureLokiStackGateway_MissingSecret(t *testing.T) {
+	resources := Options{
+		ClusterName: "cluster-1",
+		ClusterLogForwarders: []loggingv1.ClusterLogForwarder{
+			{
+				Spec: loggingv1.ClusterLogForwarderSpec{
+					Outputs: []loggingv1.OutputSpec{
+						{
+							Name: "loki",
+							Type: loggingv1.OutputTypeLoki,
+						},
+					},
+				},
+			},
+		},
+		LokiStackGateway: &LokiStackGateway{
+			URL: "https://gateway/api/logs/v1",
+			CA:  "gateway-ca",
+		},
+	}
+
+	err := configureLokiStackGateway(&resources)
+	require.Equal(t, newValidationError([]string{
+		`output "loki" sends logs to the LokiStack gateway and requires a secret`,
+	}), err)
+}
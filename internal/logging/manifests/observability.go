@@ -0,0 +1,395 @@
+package manifests
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/patch"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ObservabilityClusterLogForwarderGVK is the Logging 6 ClusterLogForwarder API.
+// The addon doesn't vendor its Go types so the resource is handled as
+// unstructured.
+var ObservabilityClusterLogForwarderGVK = schema.GroupVersionKind{
+	Group:   "observability.openshift.io",
+	Version: "v1",
+	Kind:    "ClusterLogForwarder",
+}
+
+// Output types of the observability.openshift.io/v1 ClusterLogForwarder that
+// are templated by the addon
+const (
+	observabilityOutputTypeAzureMonitor       = "azureMonitor"
+	observabilityOutputTypeCloudwatch         = "cloudwatch"
+	observabilityOutputTypeElasticsearch      = "elasticsearch"
+	observabilityOutputTypeGoogleCloudLogging = "googleCloudLogging"
+	observabilityOutputTypeHTTP               = "http"
+	observabilityOutputTypeKafka              = "kafka"
+	observabilityOutputTypeLoki               = "loki"
+	observabilityOutputTypeLokiStack          = "lokiStack"
+	observabilityOutputTypeSplunk             = "splunk"
+)
+
+// observabilityRequiredAuthentication lists for each output type the
+// authentication field that must reference a secret, the equivalent of
+// requiredSecretKeys for observability.openshift.io/v1 outputs
+var observabilityRequiredAuthentication = map[string][]string{
+	observabilityOutputTypeCloudwatch:         {observabilityOutputTypeCloudwatch, "authentication"},
+	observabilityOutputTypeGoogleCloudLogging: {observabilityOutputTypeGoogleCloudLogging, "authentication", "credentials"},
+	observabilityOutputTypeSplunk:             {observabilityOutputTypeSplunk, "authentication", "token"},
+}
+
+var observabilityReservedInputNames = map[string]struct{}{
+	"application":    {},
+	"infrastructure": {},
+	"audit":          {},
+}
+
+// UseObservabilityAPI returns true when the logging subscription channel
+// installs Logging 6 or newer, which only serves the
+// observability.openshift.io/v1 ClusterLogForwarder API.
+func UseObservabilityAPI(resources Options) bool {
+	channel := buildSubscriptionChannel(resources)
+	version := channel[strings.LastIndex(channel, "-")+1:]
+	major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	if err != nil {
+		return false
+	}
+	return major >= observabilityAPIMinMajorVersion
+}
+
+func buildObservabilityClusterLogForwarderSpec(resources Options, clf *unstructured.Unstructured) (map[string]interface{}, error) {
+	spec, _, err := unstructured.NestedMap(clf.Object, "spec")
+	if err != nil {
+		return nil, kverrors.Wrap(err, "failed to read ClusterLogForwarder spec", "name", clf.GetName())
+	}
+	if spec == nil {
+		spec = map[string]interface{}{}
+	}
+
+	if err := unstructured.SetNestedField(spec, collectorServiceAccount(clf.GetName()), "serviceAccount", "name"); err != nil {
+		return nil, err
+	}
+
+	outputs, _, err := unstructured.NestedSlice(spec, "outputs")
+	if err != nil {
+		return nil, kverrors.Wrap(err, "failed to read ClusterLogForwarder outputs", "name", clf.GetName())
+	}
+
+	values := addon.NewClusterValues(resources.ClusterName, resources.ManagedCluster)
+	for _, o := range outputs {
+		output, ok := o.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(output, "name")
+
+		if secret, ok := outputSecret(resources.Secrets, clf.GetName(), name); ok {
+			if err := templateObservabilityWithSecret(output, secret); err != nil {
+				return nil, err
+			}
+		}
+
+		// The CA bundle of the output is distributed in the trust bundle
+		// ConfigMap instead of the output secret
+		target := outputTarget(resources.Secrets, clf.GetName(), name)
+		if _, ok := resources.CABundles[target]; ok {
+			ca := map[string]interface{}{
+				"configMapName": authentication.TrustBundleName,
+				"key":           authentication.TrustBundleKey(target),
+			}
+			if err := unstructured.SetNestedMap(output, ca, "tls", "ca"); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, configmap := range resources.ConfigMaps {
+			if configmap.Annotations[AnnotationTargetOutputName] != name {
+				continue
+			}
+			if err := templateObservabilityWithConfigMap(output, configmap, values); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(outputs) > 0 {
+		if err := unstructured.SetNestedSlice(spec, outputs, "outputs"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := configureObservabilityClusterLabels(spec, resources); err != nil {
+		return nil, err
+	}
+
+	return applyObservabilityPatches(spec, clf.GetName(), resources.Patches)
+}
+
+// applyObservabilityPatches is the equivalent of applyPatches for
+// observability.openshift.io/v1 specs. Without Go types strategic merge
+// patches are applied as JSON merge patches.
+func applyObservabilityPatches(spec map[string]interface{}, name string, patches []patch.Patch) (map[string]interface{}, error) {
+	if len(patches) == 0 {
+		return spec, nil
+	}
+
+	doc, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err = patch.Apply(doc, name, patches, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	patched := map[string]interface{}{}
+	if err := json.Unmarshal(doc, &patched); err != nil {
+		return nil, kverrors.Wrap(err, "patched ClusterLogForwarder spec is invalid", "name", name)
+	}
+	return patched, nil
+}
+
+// templateObservabilityWithSecret references the keys of the secret from the
+// output fields that consume them. In this API each value is referenced
+// individually by secret name and key.
+func templateObservabilityWithSecret(output map[string]interface{}, secret corev1.Secret) error {
+	outputType, _, _ := unstructured.NestedString(output, "type")
+
+	ref := func(key string) map[string]interface{} {
+		return map[string]interface{}{
+			"secretName": secret.Name,
+			"key":        key,
+		}
+	}
+
+	fields := map[string][]string{}
+	if hasAnyKey(secret.Data, []string{"tls.crt"}) && hasAnyKey(secret.Data, []string{"tls.key"}) {
+		fields["tls.crt"] = []string{"tls", "certificate"}
+		fields["tls.key"] = []string{"tls", "key"}
+	}
+	if hasAnyKey(secret.Data, []string{"ca-bundle.crt"}) {
+		fields["ca-bundle.crt"] = []string{"tls", "ca"}
+	}
+
+	switch outputType {
+	case observabilityOutputTypeElasticsearch, observabilityOutputTypeHTTP, observabilityOutputTypeLoki:
+		if hasAnyKey(secret.Data, []string{"username"}) && hasAnyKey(secret.Data, []string{"password"}) {
+			fields["username"] = []string{outputType, "authentication", "username"}
+			fields["password"] = []string{outputType, "authentication", "password"}
+		}
+	case observabilityOutputTypeSplunk:
+		if hasAnyKey(secret.Data, []string{"hecToken"}) {
+			fields["hecToken"] = []string{outputType, "authentication", "token"}
+		}
+	case observabilityOutputTypeGoogleCloudLogging:
+		if hasAnyKey(secret.Data, []string{"google-application-credentials.json"}) {
+			fields["google-application-credentials.json"] = []string{outputType, "authentication", "credentials"}
+		}
+	case observabilityOutputTypeCloudwatch:
+		switch {
+		case hasAnyKey(secret.Data, []string{"role_arn"}):
+			auth := map[string]interface{}{
+				"type": "iamRole",
+				"iamRole": map[string]interface{}{
+					"roleARN": ref("role_arn"),
+					"token": map[string]interface{}{
+						"from": "serviceAccount",
+					},
+				},
+			}
+			if err := unstructured.SetNestedMap(output, auth, outputType, "authentication"); err != nil {
+				return err
+			}
+		case hasAnyKey(secret.Data, []string{"aws_access_key_id"}):
+			auth := map[string]interface{}{
+				"type": "awsAccessKey",
+				"awsAccessKey": map[string]interface{}{
+					"keyId":     ref("aws_access_key_id"),
+					"keySecret": ref("aws_secret_access_key"),
+				},
+			}
+			if err := unstructured.SetNestedMap(output, auth, outputType, "authentication"); err != nil {
+				return err
+			}
+		}
+	}
+
+	for key, path := range fields {
+		if err := unstructured.SetNestedMap(output, ref(key), path...); err != nil {
+			return kverrors.Wrap(err, "failed to reference secret key in output", "secret", secret.Name, "key", key)
+		}
+	}
+
+	return nil
+}
+
+func templateObservabilityWithConfigMap(output map[string]interface{}, configmap corev1.ConfigMap, values addon.ClusterValues) error {
+	outputType, _, _ := unstructured.NestedString(output, "type")
+
+	if url, ok := configmap.Data[outputURLKey]; ok {
+		// LokiStack outputs are configured with the name of the LokiStack
+		// instead of an URL
+		if outputType == "" || outputType == observabilityOutputTypeLokiStack {
+			return kverrors.New("url can't be configured for output", "configmap", configmap.Name, "type", outputType)
+		}
+
+		if err := unstructured.SetNestedField(output, url, outputType, "url"); err != nil {
+			return err
+		}
+	}
+
+	return configureObservabilityTenant(output, configmap, values)
+}
+
+// validateObservabilityClusterLogForwarderSpec checks that the names used in
+// the templated spec are unique, that pipelines only reference existing
+// inputs and outputs and that outputs have an URL and the secrets they need.
+func validateObservabilityClusterLogForwarderSpec(spec map[string]interface{}, resources Options) error {
+	var findings []string
+
+	names := func(field string) map[string]struct{} {
+		seen := map[string]struct{}{}
+		items, _, _ := unstructured.NestedSlice(spec, field)
+		for _, i := range items {
+			item, ok := i.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(item, "name")
+			if _, ok := seen[name]; ok {
+				findings = append(findings, fmt.Sprintf("duplicate %s name %q", strings.TrimSuffix(field, "s"), name))
+			}
+			seen[name] = struct{}{}
+		}
+		return seen
+	}
+
+	inputs := names("inputs")
+	outputs := names("outputs")
+	names("filters")
+
+	pipelines, _, _ := unstructured.NestedSlice(spec, "pipelines")
+	for i, p := range pipelines {
+		pipeline, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(pipeline, "name")
+		if name == "" {
+			name = fmt.Sprintf("pipeline_%d_", i)
+		}
+
+		inputRefs, _, _ := unstructured.NestedStringSlice(pipeline, "inputRefs")
+		for _, ref := range inputRefs {
+			_, ok := inputs[ref]
+			if _, reserved := observabilityReservedInputNames[ref]; !ok && !reserved {
+				findings = append(findings, fmt.Sprintf("pipeline %q references unknown input %q", name, ref))
+			}
+		}
+
+		outputRefs, _, _ := unstructured.NestedStringSlice(pipeline, "outputRefs")
+		for _, ref := range outputRefs {
+			if _, ok := outputs[ref]; !ok {
+				findings = append(findings, fmt.Sprintf("pipeline %q references unknown output %q", name, ref))
+			}
+		}
+	}
+
+	items, _, _ := unstructured.NestedSlice(spec, "outputs")
+	for _, i := range items {
+		if output, ok := i.(map[string]interface{}); ok {
+			findings = append(findings, validateObservabilityOutput(output, resources.Secrets)...)
+		}
+	}
+
+	if len(findings) > 0 {
+		return newValidationError(findings)
+	}
+	return nil
+}
+
+func validateObservabilityOutput(output map[string]interface{}, secrets []corev1.Secret) []string {
+	var findings []string
+
+	name, _, _ := unstructured.NestedString(output, "name")
+	outputType, _, _ := unstructured.NestedString(output, "type")
+
+	switch outputType {
+	case observabilityOutputTypeCloudwatch, observabilityOutputTypeGoogleCloudLogging, observabilityOutputTypeLokiStack, observabilityOutputTypeAzureMonitor:
+		// These outputs don't require an URL
+	case observabilityOutputTypeKafka:
+		url, _, _ := unstructured.NestedString(output, outputType, "url")
+		brokers, _, _ := unstructured.NestedSlice(output, outputType, "brokers")
+		if url == "" && len(brokers) == 0 {
+			findings = append(findings, fmt.Sprintf("output %q requires an url or brokers", name))
+		}
+	default:
+		if url, _, _ := unstructured.NestedString(output, outputType, "url"); url == "" {
+			findings = append(findings, fmt.Sprintf("output %q requires an url", name))
+		}
+	}
+
+	if path, ok := observabilityRequiredAuthentication[outputType]; ok {
+		if _, found, _ := unstructured.NestedFieldNoCopy(output, path...); !found {
+			findings = append(findings, fmt.Sprintf("output %q requires a secret", name))
+		}
+	}
+
+	// Every value read from a secret is referenced by secret name and key
+	for _, ref := range secretKeyRefs(output) {
+		for _, secret := range secrets {
+			if secret.Name != ref.secretName {
+				continue
+			}
+			if !hasAnyKey(secret.Data, []string{ref.key}) {
+				findings = append(findings, fmt.Sprintf("secret %q of output %q requires the key %s", secret.Name, name, ref.key))
+			}
+		}
+	}
+
+	return findings
+}
+
+type secretKeyRef struct {
+	secretName string
+	key        string
+}
+
+// secretKeyRefs returns the secret keys referenced by the fields of the
+// output, sorted by field name.
+func secretKeyRefs(field interface{}) []secretKeyRef {
+	var refs []secretKeyRef
+	switch f := field.(type) {
+	case map[string]interface{}:
+		secretName, hasSecret := f["secretName"].(string)
+		key, hasKey := f["key"].(string)
+		if hasSecret && hasKey {
+			return []secretKeyRef{{secretName: secretName, key: key}}
+		}
+
+		names := make([]string, 0, len(f))
+		for name := range f {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			refs = append(refs, secretKeyRefs(f[name])...)
+		}
+	case []interface{}:
+		for _, item := range f {
+			refs = append(refs, secretKeyRefs(item)...)
+		}
+	}
+	return refs
+}
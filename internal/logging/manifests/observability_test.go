@@ -0,0 +1,215 @@
+package manifests
+
+import (
+	"testing"
+
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+func Test_UseObservabilityAPI(t *testing.T) {
+	for _, tc := range []struct {
+		channel string
+		want    bool
+	}{
+		{channel: "", want: false},
+		{channel: "stable-5.8", want: false},
+		{channel: "stable-6.0", want: true},
+		{channel: "stable-6.1", want: true},
+		{channel: "stable", want: false},
+	} {
+		t.Run(tc.channel, func(t *testing.T) {
+			resources := Options{}
+			if tc.channel != "" {
+				resources.AddOnDeploymentConfig = &addonapiv1alpha1.AddOnDeploymentConfig{
+					Spec: addonapiv1alpha1.AddOnDeploymentConfigSpec{
+						CustomizedVariables: []addonapiv1alpha1.CustomizedVariable{
+							{Name: subscriptionChannelValueKey, Value: tc.channel},
+						},
+					},
+				}
+			}
+			require.Equal(t, tc.want, UseObservabilityAPI(resources))
+		})
+	}
+}
+
+func Test_BuildObservabilityClusterLogForwarderSpec(t *testing.T) {
+	clf := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name": "instance",
+			},
+			"spec": map[string]interface{}{
+				"outputs": []interface{}{
+					map[string]interface{}{
+						"name": "mtls",
+						"type": "http",
+					},
+					map[string]interface{}{
+						"name": "cw",
+						"type": "cloudwatch",
+					},
+				},
+			},
+		},
+	}
+
+	resources := Options{
+		CABundles: authentication.CABundles{
+			"mtls": "ca",
+		},
+		Secrets: []corev1.Secret{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "mtls-secret",
+					Annotations: map[string]string{AnnotationTargetOutputName: "mtls"},
+				},
+				Data: map[string][]byte{
+					"tls.crt": []byte("cert"),
+					"tls.key": []byte("key"),
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "cw-secret",
+					Annotations: map[string]string{AnnotationTargetOutputName: "cw"},
+				},
+				Data: map[string][]byte{
+					"role_arn": []byte("arn"),
+				},
+			},
+		},
+		ConfigMaps: []corev1.ConfigMap{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "mtls-config",
+					Annotations: map[string]string{AnnotationTargetOutputName: "mtls"},
+				},
+				Data: map[string]string{
+					"url": "https://http.example.com",
+				},
+			},
+		},
+	}
+
+	spec, err := buildObservabilityClusterLogForwarderSpec(resources, clf)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"serviceAccount": map[string]interface{}{
+			"name": collectorServiceAccountName,
+		},
+		"outputs": []interface{}{
+			map[string]interface{}{
+				"name": "mtls",
+				"type": "http",
+				"http": map[string]interface{}{
+					"url": "https://http.example.com",
+				},
+				"tls": map[string]interface{}{
+					"ca":          map[string]interface{}{"configMapName": authentication.TrustBundleName, "key": "mtls.crt"},
+					"certificate": map[string]interface{}{"secretName": "mtls-secret", "key": "tls.crt"},
+					"key":         map[string]interface{}{"secretName": "mtls-secret", "key": "tls.key"},
+				},
+			},
+			map[string]interface{}{
+				"name": "cw",
+				"type": "cloudwatch",
+				"cloudwatch": map[string]interface{}{
+					"authentication": map[string]interface{}{
+						"type": "iamRole",
+						"iamRole": map[string]interface{}{
+							"roleARN": map[string]interface{}{"secretName": "cw-secret", "key": "role_arn"},
+							"token":   map[string]interface{}{"from": "serviceAccount"},
+						},
+					},
+				},
+			},
+		},
+	}, spec)
+
+	// The template on the hub must not be modified
+	_, found, _ := unstructured.NestedString(clf.Object, "spec", "serviceAccount", "name")
+	require.False(t, found)
+}
+
+func Test_ValidateObservabilityClusterLogForwarderSpec(t *testing.T) {
+	spec := map[string]interface{}{
+		"outputs": []interface{}{
+			map[string]interface{}{
+				"name": "loki",
+				"type": "loki",
+				"loki": map[string]interface{}{"url": "https://loki"},
+			},
+			map[string]interface{}{
+				"name": "loki",
+				"type": "loki",
+				"loki": map[string]interface{}{"url": "https://loki"},
+			},
+			map[string]interface{}{
+				"name": "http",
+				"type": "http",
+			},
+			map[string]interface{}{
+				"name": "kafka",
+				"type": "kafka",
+			},
+			map[string]interface{}{
+				"name":   "splunk",
+				"type":   "splunk",
+				"splunk": map[string]interface{}{"url": "https://splunk"},
+			},
+			map[string]interface{}{
+				"name": "cw",
+				"type": "cloudwatch",
+				"cloudwatch": map[string]interface{}{
+					"authentication": map[string]interface{}{
+						"type": "awsAccessKey",
+						"awsAccessKey": map[string]interface{}{
+							"keyId":     map[string]interface{}{"secretName": "cw-secret", "key": "aws_access_key_id"},
+							"keySecret": map[string]interface{}{"secretName": "cw-secret", "key": "aws_secret_access_key"},
+						},
+					},
+				},
+			},
+		},
+		"pipelines": []interface{}{
+			map[string]interface{}{
+				"name":       "app",
+				"inputRefs":  []interface{}{"application", "missing"},
+				"outputRefs": []interface{}{"loki", "default"},
+			},
+		},
+	}
+
+	resources := Options{
+		Secrets: []corev1.Secret{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "cw-secret"},
+				Data: map[string][]byte{
+					"aws_access_key_id": []byte("id"),
+				},
+			},
+		},
+	}
+
+	err := validateObservabilityClusterLogForwarderSpec(spec, resources)
+	require.Error(t, err)
+
+	verr, ok := err.(*addon.ValidationError)
+	require.True(t, ok)
+	require.ElementsMatch(t, []string{
+		`duplicate output name "loki"`,
+		`pipeline "app" references unknown input "missing"`,
+		`pipeline "app" references unknown output "default"`,
+		`output "http" requires an url`,
+		`output "kafka" requires an url or brokers`,
+		`output "splunk" requires a secret`,
+		`secret "cw-secret" of output "cw" requires the key aws_secret_access_key`,
+	}, verr.Findings)
+}
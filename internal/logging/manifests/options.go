@@ -2,13 +2,27 @@ package manifests
 
 import (
 	loggingv1 "github.com/openshift/cluster-logging-operator/apis/logging/v1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/patch"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 )
 
 type Options struct {
-	Secrets               []corev1.Secret
-	ConfigMaps            []corev1.ConfigMap
-	ClusterLogForwarder   *loggingv1.ClusterLogForwarder
-	AddOnDeploymentConfig *addonapiv1alpha1.AddOnDeploymentConfig
+	ClusterName          string
+	Secrets              []corev1.Secret
+	ConfigMaps           []corev1.ConfigMap
+	ClusterLogForwarders []loggingv1.ClusterLogForwarder
+	// ObservabilityClusterLogForwarders are set instead of ClusterLogForwarders
+	// when the spokes run Logging 6 or newer
+	ObservabilityClusterLogForwarders []unstructured.Unstructured
+	CABundles                         authentication.CABundles
+	LokiStackGateway                  *LokiStackGateway
+	AddOnDeploymentConfig             *addonapiv1alpha1.AddOnDeploymentConfig
+	ManagedCluster                    *clusterv1.ManagedCluster
+	// Patches are applied to the templated ClusterLogForwarders, ordered
+	// from the broadest to the most specific
+	Patches []patch.Patch
 }
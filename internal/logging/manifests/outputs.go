@@ -0,0 +1,233 @@
+package manifests
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	loggingv1 "github.com/openshift/cluster-logging-operator/apis/logging/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Keys supported in the ConfigMaps annotated with AnnotationTargetOutputName.
+// Only the keys that apply to the type of the output are used.
+const (
+	outputURLKey = "url"
+
+	cloudwatchRegionKey      = "region"
+	cloudwatchGroupByKey     = "groupBy"
+	cloudwatchGroupPrefixKey = "groupPrefix"
+
+	elasticsearchStructuredTypeKeyKey  = "structuredTypeKey"
+	elasticsearchStructuredTypeNameKey = "structuredTypeName"
+	elasticsearchVersionKey            = "version"
+
+	kafkaBrokersKey = "brokers"
+	kafkaTopicKey   = "topic"
+
+	splunkFieldsKey = "fields"
+
+	httpMethodKey  = "method"
+	httpTimeoutKey = "timeout"
+	httpSchemaKey  = "schema"
+
+	syslogRFCKey        = "rfc"
+	syslogFacilityKey   = "facility"
+	syslogSeverityKey   = "severity"
+	syslogTagKey        = "tag"
+	syslogPayloadKeyKey = "payloadKey"
+
+	gclProjectIDKey        = "projectId"
+	gclFolderIDKey         = "folderId"
+	gclOrganizationIDKey   = "organizationId"
+	gclBillingAccountIDKey = "billingAccountId"
+	gclLogIDKey            = "logId"
+)
+
+// configureOutput sets the fields of the output from the ConfigMap data. The
+// url is supported by all output types while the remaining keys depend on the
+// output type. Keys that are not present in the ConfigMap leave the values of
+// the template untouched.
+func configureOutput(output *loggingv1.OutputSpec, cm corev1.ConfigMap) error {
+	if url, ok := cm.Data[outputURLKey]; ok {
+		output.URL = url
+	}
+
+	switch output.Type {
+	case loggingv1.OutputTypeCloudwatch:
+		return configureCloudwatch(output, cm)
+	case loggingv1.OutputTypeElasticsearch:
+		return configureElasticsearch(output, cm)
+	case loggingv1.OutputTypeKafka:
+		configureKafka(output, cm)
+	case loggingv1.OutputTypeSplunk:
+		configureSplunk(output, cm)
+	case loggingv1.OutputTypeHttp:
+		return configureHTTP(output, cm)
+	case loggingv1.OutputTypeSyslog:
+		configureSyslog(output, cm)
+	case loggingv1.OutputTypeGoogleCloudLogging:
+		configureGoogleCloudLogging(output, cm)
+	}
+
+	return nil
+}
+
+func configureCloudwatch(output *loggingv1.OutputSpec, cm corev1.ConfigMap) error {
+	if output.Cloudwatch == nil {
+		output.Cloudwatch = &loggingv1.Cloudwatch{}
+	}
+
+	if region, ok := cm.Data[cloudwatchRegionKey]; ok {
+		output.Cloudwatch.Region = region
+	}
+
+	if groupBy, ok := cm.Data[cloudwatchGroupByKey]; ok {
+		switch loggingv1.LogGroupByType(groupBy) {
+		case loggingv1.LogGroupByLogType, loggingv1.LogGroupByNamespaceName, loggingv1.LogGroupByNamespaceUUID:
+			output.Cloudwatch.GroupBy = loggingv1.LogGroupByType(groupBy)
+		default:
+			return kverrors.New("invalid cloudwatch groupBy in configmap", "name", cm.Name, "groupBy", groupBy)
+		}
+	}
+
+	if groupPrefix, ok := cm.Data[cloudwatchGroupPrefixKey]; ok {
+		output.Cloudwatch.GroupPrefix = &groupPrefix
+	}
+
+	return nil
+}
+
+func configureElasticsearch(output *loggingv1.OutputSpec, cm corev1.ConfigMap) error {
+	if output.Elasticsearch == nil {
+		output.Elasticsearch = &loggingv1.Elasticsearch{}
+	}
+
+	if typeKey, ok := cm.Data[elasticsearchStructuredTypeKeyKey]; ok {
+		output.Elasticsearch.StructuredTypeKey = typeKey
+	}
+
+	if typeName, ok := cm.Data[elasticsearchStructuredTypeNameKey]; ok {
+		output.Elasticsearch.StructuredTypeName = typeName
+	}
+
+	if version, ok := cm.Data[elasticsearchVersionKey]; ok {
+		v, err := strconv.Atoi(version)
+		if err != nil {
+			return kverrors.Wrap(err, "invalid elasticsearch version in configmap", "name", cm.Name, "version", version)
+		}
+		output.Elasticsearch.Version = v
+	}
+
+	return nil
+}
+
+func configureKafka(output *loggingv1.OutputSpec, cm corev1.ConfigMap) {
+	if output.Kafka == nil {
+		output.Kafka = &loggingv1.Kafka{}
+	}
+
+	if topic, ok := cm.Data[kafkaTopicKey]; ok {
+		output.Kafka.Topic = topic
+	}
+
+	if brokers, ok := cm.Data[kafkaBrokersKey]; ok {
+		output.Kafka.Brokers = splitList(brokers)
+	}
+}
+
+func configureSplunk(output *loggingv1.OutputSpec, cm corev1.ConfigMap) {
+	if output.Splunk == nil {
+		output.Splunk = &loggingv1.Splunk{}
+	}
+
+	if fields, ok := cm.Data[splunkFieldsKey]; ok {
+		output.Splunk.Fields = splitList(fields)
+	}
+}
+
+func configureHTTP(output *loggingv1.OutputSpec, cm corev1.ConfigMap) error {
+	if output.Http == nil {
+		output.Http = &loggingv1.Http{}
+	}
+
+	if method, ok := cm.Data[httpMethodKey]; ok {
+		output.Http.Method = method
+	}
+
+	if schema, ok := cm.Data[httpSchemaKey]; ok {
+		output.Http.Schema = schema
+	}
+
+	if timeout, ok := cm.Data[httpTimeoutKey]; ok {
+		t, err := strconv.Atoi(timeout)
+		if err != nil {
+			return kverrors.Wrap(err, "invalid http timeout in configmap", "name", cm.Name, "timeout", timeout)
+		}
+		output.Http.Timeout = t
+	}
+
+	return nil
+}
+
+func configureSyslog(output *loggingv1.OutputSpec, cm corev1.ConfigMap) {
+	if output.Syslog == nil {
+		output.Syslog = &loggingv1.Syslog{}
+	}
+
+	if rfc, ok := cm.Data[syslogRFCKey]; ok {
+		output.Syslog.RFC = rfc
+	}
+
+	if facility, ok := cm.Data[syslogFacilityKey]; ok {
+		output.Syslog.Facility = facility
+	}
+
+	if severity, ok := cm.Data[syslogSeverityKey]; ok {
+		output.Syslog.Severity = severity
+	}
+
+	if tag, ok := cm.Data[syslogTagKey]; ok {
+		output.Syslog.Tag = tag
+	}
+
+	if payloadKey, ok := cm.Data[syslogPayloadKeyKey]; ok {
+		output.Syslog.PayloadKey = payloadKey
+	}
+}
+
+func configureGoogleCloudLogging(output *loggingv1.OutputSpec, cm corev1.ConfigMap) {
+	if output.GoogleCloudLogging == nil {
+		output.GoogleCloudLogging = &loggingv1.GoogleCloudLogging{}
+	}
+
+	if projectID, ok := cm.Data[gclProjectIDKey]; ok {
+		output.GoogleCloudLogging.ProjectID = projectID
+	}
+
+	if folderID, ok := cm.Data[gclFolderIDKey]; ok {
+		output.GoogleCloudLogging.FolderID = folderID
+	}
+
+	if organizationID, ok := cm.Data[gclOrganizationIDKey]; ok {
+		output.GoogleCloudLogging.OrganizationID = organizationID
+	}
+
+	if billingAccountID, ok := cm.Data[gclBillingAccountIDKey]; ok {
+		output.GoogleCloudLogging.BillingAccountID = billingAccountID
+	}
+
+	if logID, ok := cm.Data[gclLogIDKey]; ok {
+		output.GoogleCloudLogging.LogID = logID
+	}
+}
+
+func splitList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,142 @@
+package manifests
+
+import (
+	"testing"
+
+	loggingv1 "github.com/openshift/cluster-logging-operator/apis/logging/v1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func Test_ConfigureOutput(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		output   loggingv1.OutputSpec
+		data     map[string]string
+		expected loggingv1.OutputSpec
+		wantErr  bool
+	}{
+		{
+			name:   "loki",
+			output: loggingv1.OutputSpec{Type: loggingv1.OutputTypeLoki},
+			data: map[string]string{
+				"url": "https://loki",
+			},
+			expected: loggingv1.OutputSpec{Type: loggingv1.OutputTypeLoki, URL: "https://loki"},
+		},
+		{
+			name: "cloudwatch",
+			output: loggingv1.OutputSpec{
+				Type: loggingv1.OutputTypeCloudwatch,
+				OutputTypeSpec: loggingv1.OutputTypeSpec{
+					Cloudwatch: &loggingv1.Cloudwatch{GroupBy: loggingv1.LogGroupByLogType},
+				},
+			},
+			data: map[string]string{
+				"region":      "eu-central-1",
+				"groupPrefix": "cluster-1",
+			},
+			expected: loggingv1.OutputSpec{
+				Type: loggingv1.OutputTypeCloudwatch,
+				OutputTypeSpec: loggingv1.OutputTypeSpec{
+					Cloudwatch: &loggingv1.Cloudwatch{
+						Region:      "eu-central-1",
+						GroupBy:     loggingv1.LogGroupByLogType,
+						GroupPrefix: ptr.To("cluster-1"),
+					},
+				},
+			},
+		},
+		{
+			name:   "cloudwatch invalid groupBy",
+			output: loggingv1.OutputSpec{Type: loggingv1.OutputTypeCloudwatch},
+			data: map[string]string{
+				"groupBy": "foo",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "elasticsearch",
+			output: loggingv1.OutputSpec{Type: loggingv1.OutputTypeElasticsearch},
+			data: map[string]string{
+				"url":                "https://es",
+				"structuredTypeName": "cluster-1",
+				"version":            "8",
+			},
+			expected: loggingv1.OutputSpec{
+				Type: loggingv1.OutputTypeElasticsearch,
+				URL:  "https://es",
+				OutputTypeSpec: loggingv1.OutputTypeSpec{
+					Elasticsearch: &loggingv1.Elasticsearch{
+						ElasticsearchStructuredSpec: loggingv1.ElasticsearchStructuredSpec{
+							StructuredTypeName: "cluster-1",
+						},
+						Version: 8,
+					},
+				},
+			},
+		},
+		{
+			name:   "kafka",
+			output: loggingv1.OutputSpec{Type: loggingv1.OutputTypeKafka},
+			data: map[string]string{
+				"brokers": "tls://broker-1:9093, tls://broker-2:9093",
+				"topic":   "cluster-1",
+			},
+			expected: loggingv1.OutputSpec{
+				Type: loggingv1.OutputTypeKafka,
+				OutputTypeSpec: loggingv1.OutputTypeSpec{
+					Kafka: &loggingv1.Kafka{
+						Topic:   "cluster-1",
+						Brokers: []string{"tls://broker-1:9093", "tls://broker-2:9093"},
+					},
+				},
+			},
+		},
+		{
+			name:   "http invalid timeout",
+			output: loggingv1.OutputSpec{Type: loggingv1.OutputTypeHttp},
+			data: map[string]string{
+				"timeout": "10s",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "googleCloudLogging",
+			output: loggingv1.OutputSpec{Type: loggingv1.OutputTypeGoogleCloudLogging},
+			data: map[string]string{
+				"projectId": "project-1",
+				"logId":     "cluster-1",
+			},
+			expected: loggingv1.OutputSpec{
+				Type: loggingv1.OutputTypeGoogleCloudLogging,
+				OutputTypeSpec: loggingv1.OutputTypeSpec{
+					GoogleCloudLogging: &loggingv1.GoogleCloudLogging{
+						ProjectID: "project-1",
+						LogID:     "cluster-1",
+					},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cm := corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cluster-1",
+					Namespace: "cluster-1",
+				},
+				Data: tc.data,
+			}
+
+			err := configureOutput(&tc.output, cm)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, tc.output)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package manifests
+
+import (
+	"strings"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	loggingv1 "github.com/openshift/cluster-logging-operator/apis/logging/v1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Keys supported in the ConfigMaps annotated with AnnotationTargetOutputName
+// to send a per cluster tenant to Loki and HTTP outputs.
+const (
+	tenantModeKey   = "tenantMode"
+	tenantKey       = "tenant"
+	tenantHeaderKey = "tenantHeader"
+
+	defaultTenantHeader = "X-Scope-OrgID"
+)
+
+// TenantMode defines how the tenant of a cluster is sent to an output
+type TenantMode string
+
+const (
+	// TenantModePath appends the tenant as the last segment of the output URL,
+	// e.g. https://lokistack-gateway/api/logs/v1/<tenant>
+	TenantModePath TenantMode = "path"
+	// TenantModeHeader sends the tenant in a header, only supported by HTTP outputs
+	TenantModeHeader TenantMode = "header"
+	// TenantModeTenantKey sets the tenantKey of Loki outputs, only supported by
+	// observability.openshift.io/v1 outputs where the tenantKey can be a static
+	// value. In logging.openshift.io/v1 it names the record field holding the
+	// tenant.
+	TenantModeTenantKey TenantMode = "tenantKey"
+)
+
+// buildTenant renders the tenant template found in the ConfigMap with the
+// variables of the cluster. When the ConfigMap doesn't set a tenant mode no
+// tenant is configured and an empty mode is returned.
+func buildTenant(cm corev1.ConfigMap, values addon.ClusterValues) (TenantMode, string, error) {
+	mode, ok := cm.Data[tenantModeKey]
+	if !ok {
+		return "", "", nil
+	}
+
+	switch TenantMode(mode) {
+	case TenantModePath, TenantModeHeader, TenantModeTenantKey:
+	default:
+		return "", "", kverrors.New("invalid tenant mode in configmap", "name", cm.Name, "tenantMode", mode)
+	}
+
+	text, ok := cm.Data[tenantKey]
+	if !ok {
+		text = addon.DefaultTenantTemplate
+	}
+
+	tenant, err := addon.RenderTenant(text, values)
+	if err != nil {
+		return "", "", kverrors.Wrap(err, "failed to render tenant template in configmap", "name", cm.Name)
+	}
+
+	return TenantMode(mode), tenant, nil
+}
+
+// configureTenant sets the cluster tenant on the output according to the
+// tenant mode of the ConfigMap.
+func configureTenant(output *loggingv1.OutputSpec, cm corev1.ConfigMap, values addon.ClusterValues) error {
+	mode, tenant, err := buildTenant(cm, values)
+	if err != nil || mode == "" {
+		return err
+	}
+
+	switch mode {
+	case TenantModePath:
+		if output.URL == "" {
+			return kverrors.New("tenant path requires an output url", "name", cm.Name, "output", output.Name)
+		}
+		output.URL = appendPath(output.URL, tenant)
+	case TenantModeHeader:
+		if output.Type != loggingv1.OutputTypeHttp {
+			return kverrors.New("tenant header is only supported by http outputs", "name", cm.Name, "output", output.Name)
+		}
+		if output.Http == nil {
+			output.Http = &loggingv1.Http{}
+		}
+		if output.Http.Headers == nil {
+			output.Http.Headers = map[string]string{}
+		}
+		output.Http.Headers[tenantHeader(cm)] = tenant
+	case TenantModeTenantKey:
+		return kverrors.New("tenantKey is only supported by Logging 6 loki outputs", "name", cm.Name, "output", output.Name)
+	}
+
+	return nil
+}
+
+// configureObservabilityTenant is the equivalent of configureTenant for
+// observability.openshift.io/v1 outputs.
+func configureObservabilityTenant(output map[string]interface{}, cm corev1.ConfigMap, values addon.ClusterValues) error {
+	mode, tenant, err := buildTenant(cm, values)
+	if err != nil || mode == "" {
+		return err
+	}
+
+	outputType, _, _ := unstructured.NestedString(output, "type")
+	name, _, _ := unstructured.NestedString(output, "name")
+
+	switch mode {
+	case TenantModePath:
+		url, _, _ := unstructured.NestedString(output, outputType, "url")
+		if url == "" {
+			return kverrors.New("tenant path requires an output url", "name", cm.Name, "output", name)
+		}
+		return unstructured.SetNestedField(output, appendPath(url, tenant), outputType, "url")
+	case TenantModeHeader:
+		if outputType != observabilityOutputTypeHTTP {
+			return kverrors.New("tenant header is only supported by http outputs", "name", cm.Name, "output", name)
+		}
+		return unstructured.SetNestedField(output, tenant, outputType, "headers", tenantHeader(cm))
+	case TenantModeTenantKey:
+		if outputType != observabilityOutputTypeLoki {
+			return kverrors.New("tenantKey is only supported by loki outputs", "name", cm.Name, "output", name)
+		}
+		return unstructured.SetNestedField(output, tenant, outputType, "tenantKey")
+	}
+
+	return nil
+}
+
+func tenantHeader(cm corev1.ConfigMap) string {
+	if header, ok := cm.Data[tenantHeaderKey]; ok && header != "" {
+		return header
+	}
+	return defaultTenantHeader
+}
+
+func appendPath(url, segment string) string {
+	return strings.TrimSuffix(url, "/") + "/" + strings.Trim(segment, "/")
+}
@@ -0,0 +1,201 @@
+package manifests
+
+import (
+	"testing"
+
+	loggingv1 "github.com/openshift/cluster-logging-operator/apis/logging/v1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+func Test_ConfigureTenant(t *testing.T) {
+	values := addon.NewClusterValues("cluster-1", &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{"env": "prod"},
+		},
+		Status: clusterv1.ManagedClusterStatus{
+			ClusterClaims: []clusterv1.ManagedClusterClaim{
+				{Name: "region.open-cluster-management.io", Value: "eu-west-1"},
+			},
+		},
+	})
+
+	for _, tc := range []struct {
+		name    string
+		data    map[string]string
+		output  loggingv1.OutputSpec
+		want    loggingv1.OutputSpec
+		wantErr bool
+	}{
+		{
+			name: "no tenant mode",
+			data: map[string]string{},
+			output: loggingv1.OutputSpec{
+				Type: loggingv1.OutputTypeLoki,
+				URL:  "https://loki",
+			},
+			want: loggingv1.OutputSpec{
+				Type: loggingv1.OutputTypeLoki,
+				URL:  "https://loki",
+			},
+		},
+		{
+			name: "cluster label",
+			data: map[string]string{
+				"tenantMode": "path",
+				"tenant":     "{{ .Labels.env }}-{{ .ClusterName }}",
+			},
+			output: loggingv1.OutputSpec{
+				Type: loggingv1.OutputTypeLoki,
+				URL:  "https://gateway/api/logs/v1",
+			},
+			want: loggingv1.OutputSpec{
+				Type: loggingv1.OutputTypeLoki,
+				URL:  "https://gateway/api/logs/v1/prod-cluster-1",
+			},
+		},
+		{
+			name: "cluster claim",
+			data: map[string]string{
+				"tenantMode": "path",
+				"tenant":     `{{ .ClusterClaims "region.open-cluster-management.io" }}`,
+			},
+			output: loggingv1.OutputSpec{
+				Type: loggingv1.OutputTypeLoki,
+				URL:  "https://gateway/api/logs/v1",
+			},
+			want: loggingv1.OutputSpec{
+				Type: loggingv1.OutputTypeLoki,
+				URL:  "https://gateway/api/logs/v1/eu-west-1",
+			},
+		},
+		{
+			name: "missing label",
+			data: map[string]string{
+				"tenantMode": "path",
+				"tenant":     "{{ .Labels.missing }}",
+			},
+			output: loggingv1.OutputSpec{
+				URL: "https://loki",
+			},
+			wantErr: true,
+		},
+		{
+			name: "path",
+			data: map[string]string{
+				"tenantMode": "path",
+			},
+			output: loggingv1.OutputSpec{
+				Type: loggingv1.OutputTypeLoki,
+				URL:  "https://gateway/api/logs/v1/",
+			},
+			want: loggingv1.OutputSpec{
+				Type: loggingv1.OutputTypeLoki,
+				URL:  "https://gateway/api/logs/v1/cluster-1",
+			},
+		},
+		{
+			name: "header with custom template",
+			data: map[string]string{
+				"tenantMode":   "header",
+				"tenant":       "fleet-{{ .ClusterName }}",
+				"tenantHeader": "X-Tenant",
+			},
+			output: loggingv1.OutputSpec{
+				Type: loggingv1.OutputTypeHttp,
+				URL:  "https://http",
+			},
+			want: loggingv1.OutputSpec{
+				Type: loggingv1.OutputTypeHttp,
+				URL:  "https://http",
+				OutputTypeSpec: loggingv1.OutputTypeSpec{
+					Http: &loggingv1.Http{
+						Headers: map[string]string{"X-Tenant": "fleet-cluster-1"},
+					},
+				},
+			},
+		},
+		{
+			name: "tenantKey",
+			data: map[string]string{
+				"tenantMode": "tenantKey",
+			},
+			output: loggingv1.OutputSpec{
+				Type: loggingv1.OutputTypeLoki,
+			},
+			wantErr: true,
+		},
+		{
+			name: "header on loki output",
+			data: map[string]string{
+				"tenantMode": "header",
+			},
+			output: loggingv1.OutputSpec{
+				Type: loggingv1.OutputTypeLoki,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid mode",
+			data: map[string]string{
+				"tenantMode": "query",
+			},
+			output:  loggingv1.OutputSpec{},
+			wantErr: true,
+		},
+		{
+			name: "invalid template",
+			data: map[string]string{
+				"tenantMode": "path",
+				"tenant":     "{{ .Unknown }}",
+			},
+			output: loggingv1.OutputSpec{
+				URL: "https://loki",
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cm := corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "tenant"},
+				Data:       tc.data,
+			}
+
+			err := configureTenant(&tc.output, cm, values)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, tc.output)
+		})
+	}
+}
+
+func Test_ConfigureObservabilityTenant(t *testing.T) {
+	output := map[string]interface{}{
+		"name": "lokistack",
+		"type": "loki",
+		"loki": map[string]interface{}{
+			"url": "https://gateway/api/logs/v1",
+		},
+	}
+
+	cm := corev1.ConfigMap{
+		Data: map[string]string{
+			"tenantMode": "path",
+		},
+	}
+
+	err := configureObservabilityTenant(output, cm, addon.NewClusterValues("cluster-1", nil))
+	require.NoError(t, err)
+	require.Equal(t, "https://gateway/api/logs/v1/cluster-1", output["loki"].(map[string]interface{})["url"])
+
+	cm.Data["tenantMode"] = "tenantKey"
+	err = configureObservabilityTenant(output, cm, addon.NewClusterValues("cluster-1", nil))
+	require.NoError(t, err)
+	require.Equal(t, "cluster-1", output["loki"].(map[string]interface{})["tenantKey"])
+}
@@ -0,0 +1,196 @@
+package manifests
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	loggingv1 "github.com/openshift/cluster-logging-operator/apis/logging/v1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// newValidationError returns a ValidationError with the findings of
+// validating a templated ClusterLogForwarder
+func newValidationError(findings []string) error {
+	return &addon.ValidationError{Kind: "ClusterLogForwarder", Findings: findings}
+}
+
+// forwarderValidationError sets the forwarder of a ValidationError, other
+// errors are returned unchanged.
+func forwarderValidationError(forwarder string, err error) error {
+	var verr *addon.ValidationError
+	if errors.As(err, &verr) {
+		verr.Name = forwarder
+	}
+	return err
+}
+
+// requiredSecretKeys lists for each output type the keys of which at least one
+// must be present in the output secret
+var requiredSecretKeys = map[string][]string{
+	loggingv1.OutputTypeCloudwatch:         {"aws_access_key_id", "role_arn", "credentials"},
+	loggingv1.OutputTypeGoogleCloudLogging: {"google-application-credentials.json"},
+	loggingv1.OutputTypeSplunk:             {"hecToken"},
+}
+
+// validateClusterLogForwarderSpec checks the templated spec against the
+// resources used to template it and returns a ValidationError with all the
+// findings or nil if the spec is valid.
+func validateClusterLogForwarderSpec(spec *loggingv1.ClusterLogForwarderSpec, resources Options) error {
+	var findings []string
+
+	inputs := map[string]struct{}{}
+	for _, input := range spec.Inputs {
+		if _, ok := inputs[input.Name]; ok {
+			findings = append(findings, fmt.Sprintf("duplicate input name %q", input.Name))
+		}
+		inputs[input.Name] = struct{}{}
+	}
+
+	outputs := map[string]loggingv1.OutputSpec{}
+	for _, output := range spec.Outputs {
+		if _, ok := outputs[output.Name]; ok {
+			findings = append(findings, fmt.Sprintf("duplicate output name %q", output.Name))
+		}
+		outputs[output.Name] = output
+	}
+
+	pipelines := map[string]struct{}{}
+	for i, pipeline := range spec.Pipelines {
+		name := pipeline.Name
+		if name == "" {
+			name = fmt.Sprintf("pipeline_%d_", i)
+		}
+		if _, ok := pipelines[name]; ok {
+			findings = append(findings, fmt.Sprintf("duplicate pipeline name %q", name))
+		}
+		pipelines[name] = struct{}{}
+
+		for _, ref := range pipeline.InputRefs {
+			if _, ok := inputs[ref]; !ok && !loggingv1.ReservedInputNames.Has(ref) {
+				findings = append(findings, fmt.Sprintf("pipeline %q references unknown input %q", name, ref))
+			}
+		}
+		for _, ref := range pipeline.OutputRefs {
+			if _, ok := outputs[ref]; !ok && !loggingv1.IsReservedOutputName(ref) {
+				findings = append(findings, fmt.Sprintf("pipeline %q references unknown output %q", name, ref))
+			}
+		}
+	}
+
+	for _, output := range spec.Outputs {
+		findings = append(findings, validateOutput(output, resources.Secrets)...)
+	}
+
+	if len(findings) > 0 {
+		return newValidationError(findings)
+	}
+	return nil
+}
+
+// validateForwarders checks that at least one ClusterLogForwarder template is
+// referenced and that their names are unique. Forwarders are rendered in the
+// same namespace on the spokes, templates from different namespaces with the
+// same name would overwrite each other.
+func validateForwarders(resources Options) error {
+	names := []string{}
+	for _, clf := range resources.ClusterLogForwarders {
+		names = append(names, clf.Name)
+	}
+	for _, clf := range resources.ObservabilityClusterLogForwarders {
+		names = append(names, clf.GetName())
+	}
+
+	if len(names) == 0 {
+		return newValidationError([]string{"no ClusterLogForwarder template is referenced by the ManagedClusterAddOn"})
+	}
+
+	var findings []string
+	seen := map[string]struct{}{}
+	for _, name := range names {
+		if _, ok := seen[name]; ok {
+			findings = append(findings, fmt.Sprintf("duplicate ClusterLogForwarder name %q", name))
+		}
+		seen[name] = struct{}{}
+	}
+
+	if len(findings) > 0 {
+		return newValidationError(findings)
+	}
+	return nil
+}
+
+// validateTargetReferences checks that the secrets and configmaps annotated
+// with AnnotationTargetOutputName reference an output of any of the forwarders,
+// either by its name or by the forwarder target of the output.
+func validateTargetReferences(outputs map[string]struct{}, resources Options) error {
+	var findings []string
+
+	for _, secret := range resources.Secrets {
+		if name, ok := secret.Annotations[AnnotationTargetOutputName]; ok {
+			if _, ok := outputs[name]; !ok {
+				findings = append(findings, fmt.Sprintf("secret %q references unknown output %q", secret.Name, name))
+			}
+		}
+	}
+
+	for _, cm := range resources.ConfigMaps {
+		if name, ok := cm.Annotations[AnnotationTargetOutputName]; ok {
+			if _, ok := outputs[name]; !ok {
+				findings = append(findings, fmt.Sprintf("configmap %q references unknown output %q", cm.Name, name))
+			}
+		}
+	}
+
+	if len(findings) > 0 {
+		return newValidationError(findings)
+	}
+	return nil
+}
+
+func validateOutput(output loggingv1.OutputSpec, secrets []corev1.Secret) []string {
+	var findings []string
+
+	switch output.Type {
+	case loggingv1.OutputTypeCloudwatch, loggingv1.OutputTypeGoogleCloudLogging:
+		// These outputs don't require an URL
+	case loggingv1.OutputTypeKafka:
+		if output.URL == "" && (output.Kafka == nil || len(output.Kafka.Brokers) == 0) {
+			findings = append(findings, fmt.Sprintf("output %q requires an url or brokers", output.Name))
+		}
+	default:
+		if output.URL == "" {
+			findings = append(findings, fmt.Sprintf("output %q requires an url", output.Name))
+		}
+	}
+
+	keys, ok := requiredSecretKeys[output.Type]
+	if !ok {
+		return findings
+	}
+
+	if output.Secret == nil {
+		return append(findings, fmt.Sprintf("output %q requires a secret", output.Name))
+	}
+
+	for _, secret := range secrets {
+		if secret.Name != output.Secret.Name {
+			continue
+		}
+		if !hasAnyKey(secret.Data, keys) {
+			findings = append(findings, fmt.Sprintf("secret %q of output %q requires one of the keys %s", secret.Name, output.Name, strings.Join(keys, ", ")))
+		}
+	}
+
+	return findings
+}
+
+func hasAnyKey(data map[string][]byte, keys []string) bool {
+	for _, key := range keys {
+		if _, ok := data[key]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package manifests
+
+import (
+	"errors"
+	"testing"
+
+	loggingv1 "github.com/openshift/cluster-logging-operator/apis/logging/v1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_ValidateClusterLogForwarderSpec(t *testing.T) {
+	spec := &loggingv1.ClusterLogForwarderSpec{
+		Inputs: []loggingv1.InputSpec{
+			{Name: "app-logs"},
+			{Name: "app-logs"},
+		},
+		Outputs: []loggingv1.OutputSpec{
+			{
+				Name: "loki",
+				Type: loggingv1.OutputTypeLoki,
+			},
+			{
+				Name:   "cloudwatch",
+				Type:   loggingv1.OutputTypeCloudwatch,
+				Secret: &loggingv1.OutputSecretSpec{Name: "logging-cloudwatch-auth"},
+			},
+			{
+				Name: "splunk",
+				Type: loggingv1.OutputTypeSplunk,
+				URL:  "https://splunk",
+			},
+		},
+		Pipelines: []loggingv1.PipelineSpec{
+			{
+				Name:       "app",
+				InputRefs:  []string{"app-logs", loggingv1.InputNameAudit, "missing-input"},
+				OutputRefs: []string{"loki", loggingv1.OutputNameDefault, "missing-output"},
+			},
+		},
+	}
+
+	resources := Options{
+		Secrets: []corev1.Secret{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "logging-cloudwatch-auth",
+					Annotations: map[string]string{
+						AnnotationTargetOutputName: "cloudwatch",
+					},
+				},
+				Data: map[string][]byte{
+					"user": []byte("foo"),
+				},
+			},
+		},
+	}
+
+	err := validateClusterLogForwarderSpec(spec, resources)
+	require.Error(t, err)
+
+	var verr *addon.ValidationError
+	require.True(t, errors.As(err, &verr))
+	require.ElementsMatch(t, []string{
+		`duplicate input name "app-logs"`,
+		`pipeline "app" references unknown input "missing-input"`,
+		`pipeline "app" references unknown output "missing-output"`,
+		`output "loki" requires an url`,
+		`secret "logging-cloudwatch-auth" of output "cloudwatch" requires one of the keys aws_access_key_id, role_arn, credentials`,
+		`output "splunk" requires a secret`,
+	}, verr.Findings)
+}
+
+func Test_ValidateClusterLogForwarderSpec_Valid(t *testing.T) {
+	spec := &loggingv1.ClusterLogForwarderSpec{
+		Outputs: []loggingv1.OutputSpec{
+			{
+				Name: "loki",
+				Type: loggingv1.OutputTypeLoki,
+				URL:  "https://loki",
+			},
+		},
+		Pipelines: []loggingv1.PipelineSpec{
+			{
+				Name:       "app",
+				InputRefs:  []string{loggingv1.InputNameApplication},
+				OutputRefs: []string{"loki"},
+			},
+		},
+	}
+
+	err := validateClusterLogForwarderSpec(spec, Options{})
+	require.NoError(t, err)
+}
+
+func Test_ValidateTargetReferences(t *testing.T) {
+	resources := Options{
+		Secrets: []corev1.Secret{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "logging-loki-auth",
+					Annotations: map[string]string{
+						AnnotationTargetOutputName: "loki",
+					},
+				},
+			},
+		},
+		ConfigMaps: []corev1.ConfigMap{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "logging-url",
+					Annotations: map[string]string{
+						AnnotationTargetOutputName: "unknown",
+					},
+				},
+			},
+		},
+	}
+
+	outputs := map[string]struct{}{"loki": {}}
+
+	err := validateTargetReferences(outputs, resources)
+	require.Error(t, err)
+
+	var verr *addon.ValidationError
+	require.True(t, errors.As(err, &verr))
+	require.Equal(t, []string{`configmap "logging-url" references unknown output "unknown"`}, verr.Findings)
+	require.Equal(t, `invalid ClusterLogForwarder: configmap "logging-url" references unknown output "unknown"`, err.Error())
+}
+
+func Test_ForwarderValidationError(t *testing.T) {
+	err := forwarderValidationError("audit", newValidationError([]string{"output \"loki\" requires an url"}))
+	require.Equal(t, `invalid ClusterLogForwarder audit: output "loki" requires an url`, err.Error())
+
+	other := errors.New("other")
+	require.Equal(t, other, forwarderValidationError("audit", other))
+}
+
+func Test_ValidateForwarders(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		resources Options
+		findings  []string
+	}{
+		{
+			name:      "no forwarder",
+			resources: Options{},
+			findings:  []string{"no ClusterLogForwarder template is referenced by the ManagedClusterAddOn"},
+		},
+		{
+			name: "duplicate names",
+			resources: Options{
+				ClusterLogForwarders: []loggingv1.ClusterLogForwarder{
+					{ObjectMeta: metav1.ObjectMeta{Namespace: "open-cluster-management", Name: "instance"}},
+					{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "instance"}},
+				},
+			},
+			findings: []string{`duplicate ClusterLogForwarder name "instance"`},
+		},
+		{
+			name: "unique names",
+			resources: Options{
+				ClusterLogForwarders: []loggingv1.ClusterLogForwarder{
+					{ObjectMeta: metav1.ObjectMeta{Namespace: "open-cluster-management", Name: "instance"}},
+					{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "audit"}},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateForwarders(tc.resources)
+			if tc.findings == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			var verr *addon.ValidationError
+			require.True(t, errors.As(err, &verr))
+			require.Equal(t, tc.findings, verr.Findings)
+		})
+	}
+}
@@ -2,42 +2,152 @@ package manifests
 
 import (
 	"encoding/json"
+
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 type LoggingValues struct {
-	Enabled                    bool          `json:"enabled"`
-	CLFSpec                    string        `json:"clfSpec"`
-	LoggingSubscriptionChannel string        `json:"loggingSubscriptionChannel"`
-	Secrets                    []SecretValue `json:"secrets"`
+	Enabled                    bool             `json:"enabled"`
+	ClusterLogForwarders       []ForwarderValue `json:"clusterLogForwarders"`
+	ClusterLoggingSpec         string           `json:"clusterLoggingSpec"`
+	LoggingSubscriptionChannel string           `json:"loggingSubscriptionChannel"`
+	Secrets                    []SecretValue    `json:"secrets"`
+	TrustBundle                string           `json:"trustBundle"`
+	ObservabilityAPI           bool             `json:"observabilityAPI"`
+}
+
+type ForwarderValue struct {
+	Name string `json:"name"`
+	Spec string `json:"spec"`
+	// ServiceAccountName is empty when the forwarder is run by the collector
+	// of the ClusterLogging instance
+	ServiceAccountName string `json:"serviceAccountName"`
 }
+
 type SecretValue struct {
 	Name string `json:"name"`
 	Data string `json:"data"`
+	// TrustBundleKey is the key of the trust bundle holding the CA bundle
+	// rendered as the ca-bundle.crt key of the secret
+	TrustBundleKey string `json:"trustBundleKey"`
 }
 
 func BuildValues(opts Options) (*LoggingValues, error) {
 	values := &LoggingValues{
-		Enabled: true,
+		Enabled:              true,
+		ClusterLogForwarders: []ForwarderValue{},
 	}
 
 	values.LoggingSubscriptionChannel = buildSubscriptionChannel(opts)
 
+	if err := validateForwarders(opts); err != nil {
+		return nil, err
+	}
+
+	if err := configureLokiStackGateway(&opts); err != nil {
+		return nil, err
+	}
+
 	secrets, err := buildSecrets(opts)
 	if err != nil {
 		return nil, err
 	}
 	values.Secrets = secrets
 
-	clfSpec, err := buildClusterLogForwarderSpec(opts)
+	trustBundle, err := authentication.BuildTrustBundle(opts.CABundles)
 	if err != nil {
 		return nil, err
 	}
+	values.TrustBundle = trustBundle
 
-	b, err := json.Marshal(clfSpec)
-	if err != nil {
+	outputs := map[string]struct{}{}
+
+	if len(opts.ObservabilityClusterLogForwarders) > 0 {
+		values.ObservabilityAPI = true
+
+		for i := range opts.ObservabilityClusterLogForwarders {
+			clf := &opts.ObservabilityClusterLogForwarders[i]
+			spec, err := buildObservabilityClusterLogForwarderSpec(opts, clf)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := configureObservabilityCollector(spec, opts); err != nil {
+				return nil, err
+			}
+
+			if err := validateObservabilityClusterLogForwarderSpec(spec, opts); err != nil {
+				return nil, forwarderValidationError(clf.GetName(), err)
+			}
+
+			items, _, _ := unstructured.NestedSlice(spec, "outputs")
+			for _, item := range items {
+				if output, ok := item.(map[string]interface{}); ok {
+					name, _, _ := unstructured.NestedString(output, "name")
+					outputs[name] = struct{}{}
+					outputs[string(forwarderOutputTarget(clf.GetName(), name))] = struct{}{}
+				}
+			}
+
+			forwarder, err := buildForwarderValue(clf.GetName(), collectorServiceAccount(clf.GetName()), spec)
+			if err != nil {
+				return nil, err
+			}
+			values.ClusterLogForwarders = append(values.ClusterLogForwarders, forwarder)
+		}
+	} else {
+		for i := range opts.ClusterLogForwarders {
+			clf := &opts.ClusterLogForwarders[i]
+			spec, err := buildClusterLogForwarderSpec(opts, clf)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := validateClusterLogForwarderSpec(spec, opts); err != nil {
+				return nil, forwarderValidationError(clf.Name, err)
+			}
+
+			for _, output := range spec.Outputs {
+				outputs[output.Name] = struct{}{}
+				outputs[string(forwarderOutputTarget(clf.Name, output.Name))] = struct{}{}
+			}
+
+			forwarder, err := buildForwarderValue(clf.Name, spec.ServiceAccountName, spec)
+			if err != nil {
+				return nil, err
+			}
+			values.ClusterLogForwarders = append(values.ClusterLogForwarders, forwarder)
+		}
+
+		clSpec, err := buildClusterLoggingSpec(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := json.Marshal(clSpec)
+		if err != nil {
+			return nil, err
+		}
+		values.ClusterLoggingSpec = string(b)
+	}
+
+	if err := validateTargetReferences(outputs, opts); err != nil {
 		return nil, err
 	}
-	values.CLFSpec = string(b)
 
 	return values, nil
 }
+
+func buildForwarderValue(name, serviceAccountName string, spec interface{}) (ForwarderValue, error) {
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return ForwarderValue{}, err
+	}
+	return ForwarderValue{
+		Name:               name,
+		Spec:               string(b),
+		ServiceAccountName: serviceAccountName,
+	}, nil
+}
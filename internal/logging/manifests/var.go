@@ -10,10 +10,26 @@ import (
 const (
 	AnnotationTargetOutputName = "logging.mcoa.openshift.io/target-output-name"
 	AnnotationCAToInject       = "logging.mcoa.openshift.io/ca"
+	AnnotationCATargets        = "logging.mcoa.openshift.io/ca-targets"
+	AnnotationCAKey            = "logging.mcoa.openshift.io/ca-key"
+	AnnotationPatch            = "logging.mcoa.openshift.io/patch"
+
+	ConditionTypeLoggingConfigurationDegraded = "LoggingConfigurationDegraded"
+
+	// caBundleSecretKey is the key of the output secrets holding the CA
+	caBundleSecretKey = "ca-bundle.crt"
 
 	subscriptionChannelValueKey = "loggingSubscriptionChannel"
 	defaultLoggingVersion       = "stable-5.8"
 
+	// Logging 6 replaced the logging.openshift.io API with observability.openshift.io
+	observabilityAPIMinMajorVersion = 6
+	collectorServiceAccountName     = "mcoa-logcollector"
+
+	// The forwarder named instance is run by the collector of the
+	// ClusterLogging instance in Logging 5
+	defaultForwarderName = "instance"
+
 	certOrganizatonalUnit = "multicluster-observability-addon"
 	certDNSNameCollector  = "collector.openshift-logging.svc"
 
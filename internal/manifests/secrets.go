@@ -16,7 +16,6 @@ const (
 	rootCertName         = "mcoa-root-certificate"
 	clusterIssuerName    = "mcoa-cluster-issuer"
 	certManagerNamespace = "cert-manager"
-	caKey                = "ca-bundle.crt"
 )
 
 type StaticAuthenticationConfig struct {
@@ -24,7 +23,6 @@ type StaticAuthenticationConfig struct {
 }
 
 type MTLSConfig struct {
-	CAToInject string
 	CommonName string
 	Subject    *certmanagerv1.X509Subject
 	DNSNames   []string
@@ -54,7 +52,7 @@ func BuildStaticSecret(ctx context.Context, k client.Client, key client.ObjectKe
 // BuildCertificate generates a Kubernetes secret for mTLS authentication. This is
 // done using Cert-Manager CR.
 func BuildCertificate(key client.ObjectKey, mTLSConfig MTLSConfig) (*certmanagerv1.Certificate, error) {
-	certKey := client.ObjectKey{Name: fmt.Sprintf("%s-cert", key.Name), Namespace: key.Namespace}
+	certKey := CertificateKey(key)
 	certManagerCert := &certmanagerv1.Certificate{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      certKey.Name,
@@ -84,6 +82,12 @@ func BuildCertificate(key client.ObjectKey, mTLSConfig MTLSConfig) (*certmanager
 	return certManagerCert, nil
 }
 
+// CertificateKey returns the key of the Cert-Manager Certificate that issues
+// the secret with the provided key.
+func CertificateKey(secretKey client.ObjectKey) client.ObjectKey {
+	return client.ObjectKey{Name: fmt.Sprintf("%s-cert", secretKey.Name), Namespace: secretKey.Namespace}
+}
+
 // createMCOSecret creates a Kubernetes secret for authentication using the
 // credentials provided by MCO
 // TODO (JoaoBraveCoding) Not implemented
@@ -162,7 +166,3 @@ func BuildAllRootCertificate() []client.Object {
 	}
 	return []client.Object{issuer, cert, cIssuer}
 }
-
-func InjectCA(secret *corev1.Secret, ca string) {
-	secret.Data[caKey] = []byte(ca)
-}
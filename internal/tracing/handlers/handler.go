@@ -8,35 +8,96 @@ import (
 	otelv1alpha1 "github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
 	"github.com/rhobs/multicluster-observability-addon/internal/addon"
 	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/patch"
 	"github.com/rhobs/multicluster-observability-addon/internal/tracing/manifests"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/klog/v2"
 	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
 	AnnotationCAToInject           = "tracing.mcoa.openshift.io/ca"
+	AnnotationCATargets            = "tracing.mcoa.openshift.io/ca-targets"
+	AnnotationCAKey                = "tracing.mcoa.openshift.io/ca-key"
+	AnnotationPatch                = "tracing.mcoa.openshift.io/patch"
+	AnnotationSampling             = "tracing.mcoa.openshift.io/sampling"
 	opentelemetryCollectorResource = "opentelemetrycollectors"
+	instrumentationResource        = "instrumentations"
 )
 
-func BuildOptions(k8s client.Client, mcAddon *addonapiv1alpha1.ManagedClusterAddOn, adoc *addonapiv1alpha1.AddOnDeploymentConfig) (manifests.Options, error) {
+const (
+	// AnnotationCollector set to CollectorAgent marks the OpenTelemetryCollector
+	// template of the agent collectors, the other template is the gateway
+	AnnotationCollector = "tracing.mcoa.openshift.io/collector"
+	CollectorAgent      = "agent"
+)
+
+func BuildOptions(k8s client.Client, cluster *clusterv1.ManagedCluster, mcAddon *addonapiv1alpha1.ManagedClusterAddOn, adoc *addonapiv1alpha1.AddOnDeploymentConfig) (manifests.Options, error) {
 	resources := manifests.Options{
 		AddOnDeploymentConfig: adoc,
 		ClusterName:           mcAddon.Namespace,
+		ManagedCluster:        cluster,
 	}
 
-	klog.Info("Retrieving OpenTelemetry Collector template")
-	key := addon.GetObjectKey(mcAddon.Status.ConfigReferences, otelv1alpha1.GroupVersion.Group, opentelemetryCollectorResource)
-	otelCol := &otelv1alpha1.OpenTelemetryCollector{}
-	if err := k8s.Get(context.Background(), key, otelCol, &client.GetOptions{}); err != nil {
-		return resources, err
+	klog.Info("Retrieving OpenTelemetry Collector templates")
+	// Templates can use expressions evaluated with the variables of the cluster
+	values := addon.NewClusterValues(mcAddon.Namespace, cluster)
+	useV1Beta1 := manifests.UseV1Beta1API(resources)
+	for _, key := range addon.GetObjectKeys(mcAddon.Status.ConfigReferences, otelv1alpha1.GroupVersion.Group, opentelemetryCollectorResource) {
+		otelCol, template, err := getOtelColTemplate(k8s, key, values, useV1Beta1)
+		if err != nil {
+			return resources, err
+		}
+
+		// If the template has the agent annotation then it's the template of the agent collectors
+		if otelCol.Annotations[AnnotationCollector] == CollectorAgent {
+			resources.AgentOpenTelemetryCollector = otelCol
+			resources.AgentOpenTelemetryCollectorV1Beta1 = template
+			continue
+		}
+
+		if resources.OpenTelemetryCollector != nil {
+			return resources, &addon.ValidationError{
+				Kind:     "OpenTelemetryCollector",
+				Findings: []string{fmt.Sprintf("templates %s and %s are both gateway templates, annotate the agent template with %s: %s", resources.OpenTelemetryCollector.Name, otelCol.Name, AnnotationCollector, CollectorAgent)},
+			}
+		}
+		resources.OpenTelemetryCollector = otelCol
+		resources.OpenTelemetryCollectorV1Beta1 = template
+	}
+	if resources.OpenTelemetryCollector == nil {
+		return resources, kverrors.New("no OpenTelemetry Collector template referenced", "cluster", mcAddon.Namespace)
 	}
-	resources.OpenTelemetryCollector = otelCol
 	klog.Info("OpenTelemetry Collector template found")
 
-	var authCM *corev1.ConfigMap = nil
-	var caSecret *corev1.Secret = nil
+	// The Instrumentation template is optional
+	key := addon.GetObjectKey(mcAddon.Status.ConfigReferences, otelv1alpha1.GroupVersion.Group, instrumentationResource)
+	if key.Name != "" {
+		instrumentation := &otelv1alpha1.Instrumentation{}
+		if err := k8s.Get(context.Background(), key, instrumentation, &client.GetOptions{}); err != nil {
+			return resources, err
+		}
+		if err := addon.RenderClusterTemplate(&instrumentation.Spec, values); err != nil {
+			return resources, kverrors.Wrap(err, "failed to render Instrumentation template", "name", instrumentation.Name)
+		}
+		resources.Instrumentation = instrumentation
+	}
+
+	gateway, err := discoverTempoStackGateway(k8s, adoc)
+	if err != nil {
+		return resources, err
+	}
+	resources.TempoStackGateway = gateway
+
+	var authCMs, patchCMs []corev1.ConfigMap
+	var samplingCMs []corev1.ConfigMap
+	caBundles := authentication.CABundles{}
 
 	for _, config := range mcAddon.Spec.Configs {
 		key := client.ObjectKey{Name: config.Name, Namespace: config.Namespace}
@@ -54,13 +115,29 @@ func BuildOptions(k8s client.Client, mcAddon *addonapiv1alpha1.ManagedClusterAdd
 				continue
 			}
 
+			// If the cm has the ca annotation then it's a configmap containing a ca
+			if _, ok := cm.Annotations[AnnotationCAToInject]; ok {
+				if err := authentication.AddCAFromConfigMap(caBundles, cm, AnnotationCATargets, AnnotationCAKey); err != nil {
+					return resources, err
+				}
+				continue
+			}
+
+			// If a cm has the patch annotation then it's patching the OpenTelemetry Collector
+			if _, ok := cm.Annotations[AnnotationPatch]; ok {
+				patchCMs = append(patchCMs, *cm)
+				continue
+			}
+
+			// If a cm has the sampling annotation then it's setting the sampling policy
+			if _, ok := cm.Annotations[AnnotationSampling]; ok {
+				samplingCMs = append(samplingCMs, *cm)
+				continue
+			}
+
 			// If a cm doesn't have a target annotation then it's configuring authentication
 			if _, ok := cm.Annotations[manifests.AnnotationTargetOutputName]; !ok {
-				if authCM != nil {
-					klog.Warning(fmt.Sprintf("auth configmap already set to %s. new configmap %s", authCM.Name, cm.Name))
-				}
-				klog.Info("auth configmap set")
-				authCM = cm
+				authCMs = append(authCMs, *cm)
 				continue
 			}
 
@@ -78,38 +155,56 @@ func BuildOptions(k8s client.Client, mcAddon *addonapiv1alpha1.ManagedClusterAdd
 				continue
 			}
 
-			// If the secret has the ca annotation then it's the secret containing the ca
+			// If the secret has the ca annotation then it's a secret containing a ca
 			if _, ok := secret.Annotations[AnnotationCAToInject]; ok {
-				caSecret = secret
+				if err := authentication.AddCAFromSecret(caBundles, secret, AnnotationCATargets, AnnotationCAKey); err != nil {
+					return resources, err
+				}
 				continue
 			}
 		}
 	}
 
+	resources.Patches, err = patch.ForCluster(patchCMs, AnnotationPatch, cluster)
+	if err != nil {
+		return resources, err
+	}
+	resources.Sampling = patch.SelectConfigMaps(samplingCMs, cluster)
+
 	ctx := context.Background()
 	authConfig := manifests.AuthDefaultConfig
 	authConfig.MTLSConfig.CommonName = mcAddon.Namespace
-	if caSecret == nil {
+	if len(caBundles) == 0 {
 		klog.Warning("no CA was found")
-	} else if len(caSecret.Data) > 0 {
-		if ca, ok := caSecret.Data["ca.crt"]; ok {
-			authConfig.MTLSConfig.CAToInject = string(ca)
-		} else {
-			return resources, kverrors.New("missing ca bundle in secret", "key", "ca.crt")
-		}
 	}
 
-	if authCM != nil {
+	// Auth ConfigMaps in the cluster namespace override the defaults
+	targetAuthType, conflicts := authentication.MergeAuthConfigMaps(mcAddon.Namespace, authCMs)
+	if err := authentication.ReportAuthConfigConflicts(ctx, k8s, mcAddon, addon.Tracing, conflicts); err != nil {
+		klog.Error(err, "failed to report auth configmaps conflicts")
+	}
+
+	if len(targetAuthType) > 0 {
+		resources.CABundles = caBundles.Resolve(authentication.MTLSTargets(targetAuthType)...)
+
 		secretsProvider, err := authentication.NewSecretsProvider(k8s, mcAddon.Namespace, addon.Tracing, authConfig)
 		if err != nil {
 			return resources, err
 		}
 
-		targetsSecret, err := secretsProvider.GenerateSecrets(ctx, authentication.BuildAuthenticationMap(authCM.Data))
+		targetsSecret, err := secretsProvider.GenerateSecrets(ctx, targetAuthType)
 		if err != nil {
 			return resources, err
 		}
 
+		statuses, err := secretsProvider.CertificatesStatus(ctx, targetAuthType, targetsSecret)
+		if err != nil {
+			return resources, err
+		}
+		if err := secretsProvider.ReportCertificates(ctx, mcAddon, statuses); err != nil {
+			klog.Error(err, "failed to report certificates status")
+		}
+
 		resources.Secrets, err = secretsProvider.FetchSecrets(ctx, targetsSecret, manifests.AnnotationTargetOutputName)
 		if err != nil {
 			return resources, err
@@ -118,3 +213,54 @@ func BuildOptions(k8s client.Client, mcAddon *addonapiv1alpha1.ManagedClusterAdd
 
 	return resources, nil
 }
+
+// getOtelColTemplate fetches the OpenTelemetryCollector template with the API
+// version in use, converts its configuration to that version and renders its
+// spec with the variables of the cluster. A v1beta1 template is returned
+// along with its v1alpha1 conversion.
+func getOtelColTemplate(k8s client.Client, key client.ObjectKey, values addon.ClusterValues, useV1Beta1 bool) (*otelv1alpha1.OpenTelemetryCollector, *unstructured.Unstructured, error) {
+	template := &unstructured.Unstructured{}
+	template.SetGroupVersionKind(otelv1alpha1.GroupVersion.WithKind(manifests.OpenTelemetryCollectorV1Beta1GVK.Kind))
+	if useV1Beta1 {
+		template.SetGroupVersionKind(manifests.OpenTelemetryCollectorV1Beta1GVK)
+	}
+	if err := k8s.Get(context.Background(), key, template, &client.GetOptions{}); err != nil {
+		return nil, nil, err
+	}
+	if err := manifests.ConvertConfig(template, useV1Beta1); err != nil {
+		return nil, nil, err
+	}
+	if spec, ok := template.Object["spec"]; ok {
+		if err := addon.RenderClusterTemplate(&spec, values); err != nil {
+			return nil, nil, kverrors.Wrap(err, "failed to render OpenTelemetry Collector template", "name", template.GetName())
+		}
+		template.Object["spec"] = spec
+	}
+
+	if !useV1Beta1 {
+		otelCol := &otelv1alpha1.OpenTelemetryCollector{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(template.Object, otelCol); err != nil {
+			return nil, nil, kverrors.Wrap(err, "failed to read OpenTelemetry Collector template", "name", template.GetName())
+		}
+		return otelCol, nil, nil
+	}
+
+	otelCol, err := manifests.ConvertFromV1Beta1(template)
+	if err != nil {
+		return nil, nil, err
+	}
+	return otelCol, template, nil
+}
+
+// InstrumentationCondition returns the ManagedClusterAddOn condition
+// reporting the instrumentation namespaces that don't exist on the cluster.
+// The namespaces are only known to the work agent of the cluster, which
+// reports the Instrumentations it couldn't apply in the status of the addon
+// ManifestWorks.
+func InstrumentationCondition(k8s client.Client, clusterName string, namespaces []string) (metav1.Condition, error) {
+	works := &workv1.ManifestWorkList{}
+	if err := k8s.List(context.Background(), works, client.InNamespace(clusterName), client.MatchingLabels{addonapiv1alpha1.AddonLabelKey: addon.Name}); err != nil {
+		return metav1.Condition{}, err
+	}
+	return manifests.InstrumentationCondition(manifests.MissingInstrumentationNamespaces(works.Items, namespaces)), nil
+}
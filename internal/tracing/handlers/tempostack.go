@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
+	"github.com/rhobs/multicluster-observability-addon/internal/tracing/manifests"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// tempoStackGVK is the API of the TempoStack owning the gateway Route
+var tempoStackGVK = schema.GroupVersionKind{
+	Group:   "tempo.grafana.com",
+	Version: "v1alpha1",
+	Kind:    "TempoStack",
+}
+
+const (
+	// AddOnDeploymentConfig variables enabling the discovery of the TempoStack
+	// gateway, the Route and CA expect a <namespace>/<name> reference
+	tempoStackGatewayRouteKey = "tracingTempoStackGatewayRoute"
+	tempoStackGatewayCAKey    = "tracingTempoStackGatewayCA"
+	tempoStackTenantKey       = "tracingTempoStackTenant"
+)
+
+// discoverTempoStackGateway looks up the TempoStack gateway Route configured
+// in the AddOnDeploymentConfig and the tenants of the TempoStack owning it.
+// The gateway CA is read from the ConfigMap configured in the
+// AddOnDeploymentConfig or, if none is set, from the Route TLS configuration.
+// Returns nil when the discovery is not configured.
+func discoverTempoStackGateway(k8s client.Client, adoc *addonapiv1alpha1.AddOnDeploymentConfig) (*manifests.TempoStackGateway, error) {
+	routeRef := addon.CustomizedVariable(adoc, tempoStackGatewayRouteKey)
+	if routeRef == "" {
+		return nil, nil
+	}
+
+	routeKey, err := addon.ParseObjectKey(routeRef)
+	if err != nil {
+		return nil, err
+	}
+
+	route := &routev1.Route{}
+	if err := k8s.Get(context.Background(), routeKey, route, &client.GetOptions{}); err != nil {
+		return nil, kverrors.Wrap(err, "failed to get TempoStack gateway route", "name", routeKey.Name, "namespace", routeKey.Namespace)
+	}
+
+	tenants, err := discoverTempoStackTenants(k8s, route)
+	if err != nil {
+		return nil, err
+	}
+
+	gateway := &manifests.TempoStackGateway{
+		Host:    route.Spec.Host,
+		Tenant:  addon.CustomizedVariable(adoc, tempoStackTenantKey),
+		Tenants: tenants,
+	}
+
+	if route.Spec.TLS != nil {
+		gateway.CA = route.Spec.TLS.CACertificate
+	}
+
+	if caRef := addon.CustomizedVariable(adoc, tempoStackGatewayCAKey); caRef != "" {
+		caKey, err := addon.ParseObjectKey(caRef)
+		if err != nil {
+			return nil, err
+		}
+
+		cm := &corev1.ConfigMap{}
+		if err := k8s.Get(context.Background(), caKey, cm, &client.GetOptions{}); err != nil {
+			return nil, kverrors.Wrap(err, "failed to get TempoStack gateway CA", "name", caKey.Name, "namespace", caKey.Namespace)
+		}
+
+		ca, ok := cm.Data[authentication.DefaultConfigMapCAKey]
+		if !ok {
+			return nil, kverrors.New("missing ca bundle in configmap", "name", cm.Name, "namespace", cm.Namespace, "key", authentication.DefaultConfigMapCAKey)
+		}
+		gateway.CA = ca
+	}
+
+	return gateway, nil
+}
+
+// discoverTempoStackTenants returns the names of the tenants configured in the
+// TempoStack owning the gateway Route. The addon doesn't vendor the TempoStack
+// Go types so it's handled as unstructured.
+func discoverTempoStackTenants(k8s client.Client, route *routev1.Route) ([]string, error) {
+	var owner *metav1.OwnerReference
+	for i, ref := range route.OwnerReferences {
+		if ref.Kind == tempoStackGVK.Kind && strings.HasPrefix(ref.APIVersion, tempoStackGVK.Group+"/") {
+			owner = &route.OwnerReferences[i]
+			break
+		}
+	}
+	if owner == nil {
+		return nil, nil
+	}
+
+	tempoStack := &unstructured.Unstructured{}
+	tempoStack.SetGroupVersionKind(tempoStackGVK)
+	key := client.ObjectKey{Name: owner.Name, Namespace: route.Namespace}
+	if err := k8s.Get(context.Background(), key, tempoStack, &client.GetOptions{}); err != nil {
+		return nil, kverrors.Wrap(err, "failed to get TempoStack", "name", key.Name, "namespace", key.Namespace)
+	}
+
+	tenantsAuth, _, err := unstructured.NestedSlice(tempoStack.Object, "spec", "tenants", "authentication")
+	if err != nil {
+		return nil, kverrors.Wrap(err, "failed to read TempoStack tenants", "name", key.Name, "namespace", key.Namespace)
+	}
+
+	tenants := []string{}
+	for _, item := range tenantsAuth {
+		tenant, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, _, _ := unstructured.NestedString(tenant, "tenantName"); name != "" {
+			tenants = append(tenants, name)
+		}
+	}
+	return tenants, nil
+}
@@ -1,10 +1,14 @@
 package tracing
 
 import (
+	"fmt"
+	"net/url"
 	"os"
+	"strconv"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 
 	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	otelv1alpha1 "github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
@@ -13,8 +17,10 @@ import (
 	"github.com/rhobs/multicluster-observability-addon/internal/addon"
 	"github.com/rhobs/multicluster-observability-addon/internal/tracing/handlers"
 	"github.com/rhobs/multicluster-observability-addon/internal/tracing/manifests"
+	"github.com/rhobs/multicluster-observability-addon/internal/tracing/manifests/otelcol"
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog/v2"
 	"open-cluster-management.io/addon-framework/pkg/addonfactory"
@@ -39,7 +45,26 @@ func fakeGetValues(k8s client.Client) addonfactory.GetValuesFunc {
 		cluster *clusterv1.ManagedCluster,
 		addon *addonapiv1alpha1.ManagedClusterAddOn,
 	) (addonfactory.Values, error) {
-		opts, err := handlers.BuildOptions(k8s, addon, nil)
+		opts, err := handlers.BuildOptions(k8s, cluster, addon, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		tracing, err := manifests.BuildValues(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		return addonfactory.JsonStructToValues(tracing)
+	}
+}
+
+func fakeGetValuesWithConfig(k8s client.Client, adoc *addonapiv1alpha1.AddOnDeploymentConfig) addonfactory.GetValuesFunc {
+	return func(
+		cluster *clusterv1.ManagedCluster,
+		addon *addonapiv1alpha1.ManagedClusterAddOn,
+	) (addonfactory.Values, error) {
+		opts, err := handlers.BuildOptions(k8s, cluster, addon, adoc)
 		if err != nil {
 			return nil, err
 		}
@@ -110,7 +135,7 @@ func Test_Tracing_AllConfigsTogether_AllResources(t *testing.T) {
 	}
 
 	// Setup configuration resources: OpenTelemetryCollector, AddOnDeploymentConfig
-	b, err := os.ReadFile("./manifests/otelcol/test_data/simplest.yaml")
+	b, err := os.ReadFile("./manifests/otelcol/test_data/basic_otelhttp.yaml")
 	require.NoError(t, err)
 	otelColConfig := string(b)
 
@@ -185,7 +210,7 @@ func Test_Tracing_AllConfigsTogether_AllResources(t *testing.T) {
 	// Render manifests and return them as k8s runtime objects
 	objects, err := tracingAgentAddon.Manifests(managedCluster, managedClusterAddOn)
 	require.NoError(t, err)
-	require.Equal(t, 6, len(objects))
+	require.Equal(t, 8, len(objects))
 
 	for _, obj := range objects {
 		switch obj := obj.(type) {
@@ -193,6 +218,9 @@ func Test_Tracing_AllConfigsTogether_AllResources(t *testing.T) {
 			require.Equal(t, "spoke-otelcol", obj.ObjectMeta.Name)
 			require.Equal(t, "spoke-otelcol", obj.ObjectMeta.Namespace)
 			require.NotEmpty(t, obj.Spec.Config)
+			require.Contains(t, obj.Spec.Config, "k8s.cluster.name")
+		case *rbacv1.ClusterRoleBinding:
+			require.Equal(t, "spoke-otelcol-collector", obj.Subjects[0].Name)
 		case *corev1.Secret:
 			if obj.Name == "tracing-otlphttp-auth" {
 				require.Equal(t, generatedSecret.Data, obj.Data)
@@ -200,3 +228,645 @@ func Test_Tracing_AllConfigsTogether_AllResources(t *testing.T) {
 		}
 	}
 }
+
+func Test_Tracing_V1Beta1(t *testing.T) {
+	managedCluster := addontesting.NewManagedCluster("cluster-1")
+
+	managedClusterAddOn := addontesting.NewAddon("test", "cluster-1")
+	managedClusterAddOn.Spec.Configs = []addonapiv1alpha1.AddOnConfig{
+		{
+			ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+				Group:    "",
+				Resource: "configmaps",
+			},
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{
+				Namespace: "open-cluster-management",
+				Name:      "tracing-auth",
+			},
+		},
+	}
+	managedClusterAddOn.Status.ConfigReferences = []addonapiv1alpha1.ConfigReference{
+		{
+			ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+				Group:    "opentelemetry.io",
+				Resource: "opentelemetrycollectors",
+			},
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{
+				Namespace: "open-cluster-management",
+				Name:      "spoke-otelcol",
+			},
+		},
+	}
+
+	otelCol := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":      "spoke-otelcol",
+				"namespace": "open-cluster-management",
+			},
+			"spec": map[string]interface{}{
+				"mode": "deployment",
+				"daemonSetUpdateStrategy": map[string]interface{}{
+					"type": "RollingUpdate",
+				},
+				"config": map[string]interface{}{
+					"receivers": map[string]interface{}{
+						"otlp": map[string]interface{}{
+							"protocols": map[string]interface{}{
+								"grpc": map[string]interface{}{},
+							},
+						},
+					},
+					"exporters": map[string]interface{}{
+						"otlphttp": map[string]interface{}{
+							"endpoint": "https://tempo/{{ .ClusterName }}",
+						},
+					},
+					"service": map[string]interface{}{
+						"pipelines": map[string]interface{}{
+							"traces": map[string]interface{}{
+								"receivers": []interface{}{"otlp"},
+								"exporters": []interface{}{"otlphttp"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	otelCol.SetGroupVersionKind(manifests.OpenTelemetryCollectorV1Beta1GVK)
+
+	addOnDeploymentConfig := &addonapiv1alpha1.AddOnDeploymentConfig{
+		Spec: addonapiv1alpha1.AddOnDeploymentConfigSpec{
+			CustomizedVariables: []addonapiv1alpha1.CustomizedVariable{
+				{
+					Name:  "tracingOpenTelemetryCollectorAPIVersion",
+					Value: "v1beta1",
+				},
+			},
+		},
+	}
+
+	authCM := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "tracing-auth",
+			Namespace: "open-cluster-management",
+			Labels: map[string]string{
+				"mcoa.openshift.io/signal": "tracing",
+			},
+		},
+		Data: map[string]string{
+			"otlphttp": "mTLS",
+		},
+	}
+
+	// This secret will be generated by cert-manager
+	generatedSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "tracing-otlphttp-auth",
+			Namespace: "cluster-1",
+		},
+		Data: map[string][]byte{
+			"tls.crt": []byte("data"),
+			"ca.crt":  []byte("data"),
+			"tls.key": []byte("data"),
+		},
+	}
+
+	fakeKubeClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(otelCol, authCM, generatedSecret).
+		Build()
+
+	tracingAgentAddon, err := addonfactory.NewAgentAddonFactory(addon.Name, addon.FS, addon.TracingChartDir).
+		WithGetValuesFuncs(fakeGetValuesWithConfig(fakeKubeClient, addOnDeploymentConfig)).
+		WithAgentRegistrationOption(&agent.RegistrationOption{}).
+		WithScheme(addon.NewRenderScheme(scheme.Scheme, manifests.OpenTelemetryCollectorV1Beta1GVK)).
+		BuildHelmAgentAddon()
+	require.NoError(t, err)
+
+	objects, err := tracingAgentAddon.Manifests(managedCluster, managedClusterAddOn)
+	require.NoError(t, err)
+
+	var found bool
+	for _, obj := range objects {
+		switch obj := obj.(type) {
+		case *otelv1alpha1.OpenTelemetryCollector:
+			require.Fail(t, "v1alpha1 OpenTelemetryCollector must not be rendered")
+		case *unstructured.Unstructured:
+			if obj.GroupVersionKind() != manifests.OpenTelemetryCollectorV1Beta1GVK {
+				continue
+			}
+			found = true
+
+			strategy, _, err := unstructured.NestedString(obj.Object, "spec", "daemonSetUpdateStrategy", "type")
+			require.NoError(t, err)
+			require.Equal(t, "RollingUpdate", strategy)
+
+			exporter, _, err := unstructured.NestedMap(obj.Object, "spec", "config", "exporters", "otlphttp")
+			require.NoError(t, err)
+			require.Equal(t, "https://tempo/cluster-1", exporter["endpoint"])
+			require.Equal(t, "/tracing-otlphttp-auth/tls.crt", exporter["tls"].(map[string]interface{})["cert_file"])
+
+			processors, _, err := unstructured.NestedStringSlice(obj.Object, "spec", "config", "service", "pipelines", "traces", "processors")
+			require.NoError(t, err)
+			require.Equal(t, []string{"k8sattributes/mcoa", "resource/mcoa-cluster"}, processors)
+
+			volumes, _, err := unstructured.NestedSlice(obj.Object, "spec", "volumes")
+			require.NoError(t, err)
+			require.Len(t, volumes, 1)
+		}
+	}
+	require.True(t, found)
+}
+
+func Test_Tracing_V1Alpha1TemplateToV1Beta1(t *testing.T) {
+	managedCluster := addontesting.NewManagedCluster("cluster-1")
+
+	managedClusterAddOn := addontesting.NewAddon("test", "cluster-1")
+	managedClusterAddOn.Status.ConfigReferences = []addonapiv1alpha1.ConfigReference{
+		{
+			ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+				Group:    "opentelemetry.io",
+				Resource: "opentelemetrycollectors",
+			},
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{
+				Namespace: "open-cluster-management",
+				Name:      "spoke-otelcol",
+			},
+		},
+	}
+
+	// A hub that doesn't convert between the API versions serves the v1alpha1
+	// template with its YAML configuration
+	otelCol := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":      "spoke-otelcol",
+				"namespace": "open-cluster-management",
+			},
+			"spec": map[string]interface{}{
+				"mode":     "deployment",
+				"replicas": int64(3),
+				"config": `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+processors:
+  batch:
+    send_batch_size: 1000000
+    timeout: 10s
+exporters:
+  otlphttp:
+    endpoint: https://tempo/{{ .ClusterName }}
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [otlphttp]
+`,
+			},
+		},
+	}
+	otelCol.SetGroupVersionKind(manifests.OpenTelemetryCollectorV1Beta1GVK)
+
+	addOnDeploymentConfig := &addonapiv1alpha1.AddOnDeploymentConfig{
+		Spec: addonapiv1alpha1.AddOnDeploymentConfigSpec{
+			CustomizedVariables: []addonapiv1alpha1.CustomizedVariable{
+				{
+					Name:  "tracingOpenTelemetryCollectorAPIVersion",
+					Value: "v1beta1",
+				},
+			},
+		},
+	}
+
+	fakeKubeClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(otelCol).
+		Build()
+
+	tracingAgentAddon, err := addonfactory.NewAgentAddonFactory(addon.Name, addon.FS, addon.TracingChartDir).
+		WithGetValuesFuncs(fakeGetValuesWithConfig(fakeKubeClient, addOnDeploymentConfig)).
+		WithAgentRegistrationOption(&agent.RegistrationOption{}).
+		WithScheme(addon.NewRenderScheme(scheme.Scheme, manifests.OpenTelemetryCollectorV1Beta1GVK)).
+		BuildHelmAgentAddon()
+	require.NoError(t, err)
+
+	objects, err := tracingAgentAddon.Manifests(managedCluster, managedClusterAddOn)
+	require.NoError(t, err)
+
+	var found bool
+	for _, obj := range objects {
+		obj, ok := obj.(*unstructured.Unstructured)
+		if !ok || obj.GroupVersionKind() != manifests.OpenTelemetryCollectorV1Beta1GVK {
+			continue
+		}
+		found = true
+
+		replicas, _, err := unstructured.NestedFieldNoCopy(obj.Object, "spec", "replicas")
+		require.NoError(t, err)
+		require.Equal(t, int64(3), replicas)
+
+		batch, _, err := unstructured.NestedMap(obj.Object, "spec", "config", "processors", "batch")
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			"send_batch_size": int64(1000000),
+			"timeout":         "10s",
+		}, batch)
+
+		endpoint, _, err := unstructured.NestedString(obj.Object, "spec", "config", "exporters", "otlphttp", "endpoint")
+		require.NoError(t, err)
+		require.Equal(t, "https://tempo/cluster-1", endpoint)
+	}
+	require.True(t, found)
+}
+
+func Test_Tracing_Instrumentation(t *testing.T) {
+	managedCluster := addontesting.NewManagedCluster("cluster-1")
+
+	managedClusterAddOn := addontesting.NewAddon("test", "cluster-1")
+	managedClusterAddOn.Status.ConfigReferences = []addonapiv1alpha1.ConfigReference{
+		{
+			ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+				Group:    "opentelemetry.io",
+				Resource: "opentelemetrycollectors",
+			},
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{
+				Namespace: "open-cluster-management",
+				Name:      "spoke-otelcol",
+			},
+		},
+		{
+			ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+				Group:    "opentelemetry.io",
+				Resource: "instrumentations",
+			},
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{
+				Namespace: "open-cluster-management",
+				Name:      "instrumentation",
+			},
+		},
+	}
+
+	b, err := os.ReadFile("./manifests/otelcol/test_data/basic_otelhttp.yaml")
+	require.NoError(t, err)
+
+	otelCol := &otelv1alpha1.OpenTelemetryCollector{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "spoke-otelcol",
+			Namespace: "open-cluster-management",
+		},
+		Spec: otelv1alpha1.OpenTelemetryCollectorSpec{
+			Config: string(b),
+		},
+	}
+
+	instrumentation := &otelv1alpha1.Instrumentation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "instrumentation",
+			Namespace: "open-cluster-management",
+		},
+		Spec: otelv1alpha1.InstrumentationSpec{
+			Exporter: otelv1alpha1.Exporter{
+				Endpoint: "http://ignored:4317",
+			},
+			Sampler: otelv1alpha1.Sampler{
+				Type:     otelv1alpha1.ParentBasedTraceIDRatio,
+				Argument: "0.25",
+			},
+		},
+	}
+
+	addOnDeploymentConfig := &addonapiv1alpha1.AddOnDeploymentConfig{
+		Spec: addonapiv1alpha1.AddOnDeploymentConfigSpec{
+			CustomizedVariables: []addonapiv1alpha1.CustomizedVariable{
+				{
+					Name:  "tracingInstrumentationNamespaces",
+					Value: "app-1,app-2",
+				},
+			},
+		},
+	}
+
+	fakeKubeClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(otelCol, instrumentation).
+		Build()
+
+	tracingAgentAddon, err := addonfactory.NewAgentAddonFactory(addon.Name, addon.FS, addon.TracingChartDir).
+		WithGetValuesFuncs(fakeGetValuesWithConfig(fakeKubeClient, addOnDeploymentConfig)).
+		WithAgentRegistrationOption(&agent.RegistrationOption{}).
+		WithScheme(scheme.Scheme).
+		BuildHelmAgentAddon()
+	require.NoError(t, err)
+
+	objects, err := tracingAgentAddon.Manifests(managedCluster, managedClusterAddOn)
+	require.NoError(t, err)
+
+	namespaces := []string{}
+	for _, obj := range objects {
+		obj, ok := obj.(*otelv1alpha1.Instrumentation)
+		if !ok {
+			continue
+		}
+		namespaces = append(namespaces, obj.Namespace)
+		require.Equal(t, "mcoa-instrumentation", obj.Name)
+		require.Equal(t, "http://spoke-otelcol-collector.spoke-otelcol.svc:4317", obj.Spec.Exporter.Endpoint)
+		require.Equal(t, "0.25", obj.Spec.Sampler.Argument)
+		require.Equal(t, []corev1.EnvVar{
+			{Name: "OTEL_EXPORTER_OTLP_ENDPOINT", Value: "http://spoke-otelcol-collector.spoke-otelcol.svc:4318"},
+		}, obj.Spec.Python.Env)
+	}
+	require.Equal(t, []string{"app-1", "app-2"}, namespaces)
+}
+
+func Test_Tracing_GatewayAgent(t *testing.T) {
+	managedCluster := addontesting.NewManagedCluster("cluster-1")
+
+	managedClusterAddOn := addontesting.NewAddon("test", "cluster-1")
+	managedClusterAddOn.Status.ConfigReferences = []addonapiv1alpha1.ConfigReference{
+		{
+			ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+				Group:    "opentelemetry.io",
+				Resource: "opentelemetrycollectors",
+			},
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{
+				Namespace: "open-cluster-management",
+				Name:      "spoke-otelcol-agent",
+			},
+		},
+		{
+			ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+				Group:    "opentelemetry.io",
+				Resource: "opentelemetrycollectors",
+			},
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{
+				Namespace: "open-cluster-management",
+				Name:      "spoke-otelcol",
+			},
+		},
+	}
+
+	b, err := os.ReadFile("./manifests/otelcol/test_data/basic_otelhttp.yaml")
+	require.NoError(t, err)
+
+	gateway := &otelv1alpha1.OpenTelemetryCollector{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "spoke-otelcol",
+			Namespace: "open-cluster-management",
+		},
+		Spec: otelv1alpha1.OpenTelemetryCollectorSpec{
+			Config: string(b),
+		},
+	}
+
+	agentTemplate := &otelv1alpha1.OpenTelemetryCollector{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "spoke-otelcol-agent",
+			Namespace: "open-cluster-management",
+			Annotations: map[string]string{
+				handlers.AnnotationCollector: handlers.CollectorAgent,
+			},
+		},
+		Spec: otelv1alpha1.OpenTelemetryCollectorSpec{
+			Config: `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+`,
+		},
+	}
+
+	fakeKubeClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(gateway, agentTemplate).
+		Build()
+
+	tracingAgentAddon, err := addonfactory.NewAgentAddonFactory(addon.Name, addon.FS, addon.TracingChartDir).
+		WithGetValuesFuncs(fakeGetValues(fakeKubeClient)).
+		WithAgentRegistrationOption(&agent.RegistrationOption{}).
+		WithScheme(scheme.Scheme).
+		BuildHelmAgentAddon()
+	require.NoError(t, err)
+
+	objects, err := tracingAgentAddon.Manifests(managedCluster, managedClusterAddOn)
+	require.NoError(t, err)
+
+	collectors := map[string]*otelv1alpha1.OpenTelemetryCollector{}
+	for _, obj := range objects {
+		switch obj := obj.(type) {
+		case *otelv1alpha1.OpenTelemetryCollector:
+			collectors[obj.Name] = obj
+		case *rbacv1.ClusterRoleBinding:
+			require.Equal(t, "spoke-otelcol-agent-collector", obj.Subjects[0].Name)
+		}
+	}
+	require.Len(t, collectors, 2)
+
+	agentCol := collectors["spoke-otelcol-agent"]
+	require.Equal(t, otelv1alpha1.ModeDaemonSet, agentCol.Spec.Mode)
+	agentCfg, err := otelcol.ConfigFromString(agentCol.Spec.Config)
+	require.NoError(t, err)
+	require.Equal(t, []string{"k8sattributes/mcoa"}, agentCfg.Service.Pipelines["traces"].Processors)
+	require.Equal(t, []string{"otlp/mcoa-gateway"}, agentCfg.Service.Pipelines["traces"].Exporters)
+	require.Equal(t, "spoke-otelcol-collector.spoke-otelcol.svc:4317", agentCfg.Exporters["otlp/mcoa-gateway"]["endpoint"])
+	require.Equal(t, map[string]interface{}{"node_from_env_var": "KUBE_NODE_NAME"}, agentCfg.Processors["k8sattributes/mcoa"]["filter"])
+	require.Equal(t, "spec.nodeName", agentCol.Spec.Env[0].ValueFrom.FieldRef.FieldPath)
+
+	gatewayCfg, err := otelcol.ConfigFromString(collectors["spoke-otelcol"].Spec.Config)
+	require.NoError(t, err)
+	require.Equal(t, []string{"resource/mcoa-cluster"}, gatewayCfg.Service.Pipelines["traces"].Processors)
+}
+
+func Test_Tracing_AgentInstrumentation(t *testing.T) {
+	managedCluster := addontesting.NewManagedCluster("cluster-1")
+
+	managedClusterAddOn := addontesting.NewAddon("test", "cluster-1")
+	for _, name := range []string{"spoke-otelcol", "spoke-otelcol-agent"} {
+		managedClusterAddOn.Status.ConfigReferences = append(managedClusterAddOn.Status.ConfigReferences, addonapiv1alpha1.ConfigReference{
+			ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+				Group:    "opentelemetry.io",
+				Resource: "opentelemetrycollectors",
+			},
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{
+				Namespace: "open-cluster-management",
+				Name:      name,
+			},
+		})
+	}
+	managedClusterAddOn.Status.ConfigReferences = append(managedClusterAddOn.Status.ConfigReferences, addonapiv1alpha1.ConfigReference{
+		ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+			Group:    "opentelemetry.io",
+			Resource: "instrumentations",
+		},
+		ConfigReferent: addonapiv1alpha1.ConfigReferent{
+			Namespace: "open-cluster-management",
+			Name:      "instrumentation",
+		},
+	})
+
+	b, err := os.ReadFile("./manifests/otelcol/test_data/basic_otelhttp.yaml")
+	require.NoError(t, err)
+
+	gateway := &otelv1alpha1.OpenTelemetryCollector{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "spoke-otelcol",
+			Namespace: "open-cluster-management",
+		},
+		Spec: otelv1alpha1.OpenTelemetryCollectorSpec{
+			Config: string(b),
+		},
+	}
+
+	agentTemplate := &otelv1alpha1.OpenTelemetryCollector{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "spoke-otelcol-agent",
+			Namespace: "open-cluster-management",
+			Annotations: map[string]string{
+				handlers.AnnotationCollector: handlers.CollectorAgent,
+			},
+		},
+		Spec: otelv1alpha1.OpenTelemetryCollectorSpec{
+			Config: `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+      http:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+`,
+		},
+	}
+
+	instrumentation := &otelv1alpha1.Instrumentation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "instrumentation",
+			Namespace: "open-cluster-management",
+		},
+	}
+
+	addOnDeploymentConfig := &addonapiv1alpha1.AddOnDeploymentConfig{
+		Spec: addonapiv1alpha1.AddOnDeploymentConfigSpec{
+			CustomizedVariables: []addonapiv1alpha1.CustomizedVariable{
+				{
+					Name:  "tracingInstrumentationNamespaces",
+					Value: "app-1",
+				},
+			},
+		},
+	}
+
+	fakeKubeClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(gateway, agentTemplate, instrumentation).
+		Build()
+
+	tracingAgentAddon, err := addonfactory.NewAgentAddonFactory(addon.Name, addon.FS, addon.TracingChartDir).
+		WithGetValuesFuncs(fakeGetValuesWithConfig(fakeKubeClient, addOnDeploymentConfig)).
+		WithAgentRegistrationOption(&agent.RegistrationOption{}).
+		WithScheme(scheme.Scheme).
+		BuildHelmAgentAddon()
+	require.NoError(t, err)
+
+	objects, err := tracingAgentAddon.Manifests(managedCluster, managedClusterAddOn)
+	require.NoError(t, err)
+
+	var (
+		agentCol *otelv1alpha1.OpenTelemetryCollector
+		inst     *otelv1alpha1.Instrumentation
+		services = map[string]*corev1.Service{}
+	)
+	for _, obj := range objects {
+		switch obj := obj.(type) {
+		case *otelv1alpha1.OpenTelemetryCollector:
+			if obj.Name == "spoke-otelcol-agent" {
+				agentCol = obj
+			}
+		case *otelv1alpha1.Instrumentation:
+			inst = obj
+		case *corev1.Service:
+			services[fmt.Sprintf("%s.%s.svc", obj.Name, obj.Namespace)] = obj
+		}
+	}
+	require.NotNil(t, agentCol)
+	require.NotNil(t, inst)
+
+	// The endpoints of the Instrumentation resolve to a Service routing to
+	// the agent of the node of the instrumented pod
+	endpoints := []string{inst.Spec.Exporter.Endpoint}
+	for _, env := range inst.Spec.Python.Env {
+		if env.Name == "OTEL_EXPORTER_OTLP_ENDPOINT" {
+			endpoints = append(endpoints, env.Value)
+		}
+	}
+	require.Len(t, endpoints, 2)
+
+	for _, endpoint := range endpoints {
+		u, err := url.Parse(endpoint)
+		require.NoError(t, err)
+
+		svc, ok := services[u.Hostname()]
+		require.True(t, ok, "no Service rendered for %s", endpoint)
+		require.Equal(t, corev1.ServiceInternalTrafficPolicyLocal, *svc.Spec.InternalTrafficPolicy)
+		require.Equal(t, fmt.Sprintf("%s.%s", agentCol.Namespace, agentCol.Name), svc.Spec.Selector["app.kubernetes.io/instance"])
+		require.Equal(t, "opentelemetry-collector", svc.Spec.Selector["app.kubernetes.io/component"])
+
+		ports := []string{}
+		for _, port := range svc.Spec.Ports {
+			ports = append(ports, strconv.Itoa(int(port.Port)))
+		}
+		require.Contains(t, ports, u.Port())
+	}
+}
+
+func Test_Tracing_TwoGatewayTemplates(t *testing.T) {
+	managedCluster := addontesting.NewManagedCluster("cluster-1")
+
+	managedClusterAddOn := addontesting.NewAddon("test", "cluster-1")
+	templates := []client.Object{}
+	for _, name := range []string{"spoke-otelcol", "spoke-otelcol-other"} {
+		managedClusterAddOn.Status.ConfigReferences = append(managedClusterAddOn.Status.ConfigReferences, addonapiv1alpha1.ConfigReference{
+			ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+				Group:    "opentelemetry.io",
+				Resource: "opentelemetrycollectors",
+			},
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{
+				Namespace: "open-cluster-management",
+				Name:      name,
+			},
+		})
+		templates = append(templates, &otelv1alpha1.OpenTelemetryCollector{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "open-cluster-management",
+			},
+		})
+	}
+
+	fakeKubeClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(templates...).
+		Build()
+
+	tracingAgentAddon, err := addonfactory.NewAgentAddonFactory(addon.Name, addon.FS, addon.TracingChartDir).
+		WithGetValuesFuncs(fakeGetValues(fakeKubeClient)).
+		WithAgentRegistrationOption(&agent.RegistrationOption{}).
+		WithScheme(scheme.Scheme).
+		BuildHelmAgentAddon()
+	require.NoError(t, err)
+
+	_, err = tracingAgentAddon.Manifests(managedCluster, managedClusterAddOn)
+	var verr *addon.ValidationError
+	require.ErrorAs(t, err, &verr)
+}
@@ -0,0 +1,66 @@
+package manifests
+
+import (
+	"strings"
+
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/rhobs/multicluster-observability-addon/internal/tracing/manifests/otelcol"
+)
+
+const (
+	// AddOnDeploymentConfig variable listing the ManagedCluster labels, comma
+	// separated, added to the spans as resource attributes
+	clusterLabelsKey = "tracingClusterLabels"
+
+	clusterIDClaim = "id.openshift.io"
+)
+
+// buildClusterAttributes returns the resource attributes identifying the
+// cluster that are added to every span: the cluster name, the cluster ID
+// claimed by the cluster and the ManagedCluster labels selected in the
+// AddOnDeploymentConfig.
+func buildClusterAttributes(resources Options) map[string]string {
+	attributes := map[string]string{
+		otelcol.ClusterNameAttribute: resources.ClusterName,
+	}
+
+	cluster := resources.ManagedCluster
+	if cluster == nil {
+		return attributes
+	}
+
+	for _, claim := range cluster.Status.ClusterClaims {
+		if claim.Name == clusterIDClaim {
+			attributes[otelcol.ClusterUIDAttribute] = claim.Value
+		}
+	}
+
+	for _, key := range strings.Split(addon.CustomizedVariable(resources.AddOnDeploymentConfig, clusterLabelsKey), ",") {
+		key = strings.TrimSpace(key)
+		if value, ok := cluster.Labels[key]; ok && key != "" {
+			attributes[otelcol.ClusterLabelAttributePrefix+key] = value
+		}
+	}
+
+	return attributes
+}
+
+// configureClusterAttributes adds the cluster attributes to every traces
+// pipeline of the collector. The attributes of the pods are added by the
+// collector receiving the spans from the applications, the agents when they
+// are deployed.
+func configureClusterAttributes(resources Options) error {
+	spec := &resources.OpenTelemetryCollector.Spec
+	cfg, err := otelcol.ConfigFromString(spec.Config)
+	if err != nil {
+		return err
+	}
+
+	if resources.AgentOpenTelemetryCollector == nil {
+		otelcol.ConfigureK8sAttributes(cfg)
+	}
+	otelcol.ConfigureClusterAttributes(cfg, buildClusterAttributes(resources))
+
+	spec.Config, err = otelcol.ConfigToString(cfg)
+	return err
+}
@@ -0,0 +1,54 @@
+package manifests
+
+import (
+	"testing"
+
+	otelv1alpha1 "github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/rhobs/multicluster-observability-addon/internal/tracing/manifests/otelcol"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+func Test_ConfigureClusterAttributes(t *testing.T) {
+	resources := Options{
+		ClusterName: "cluster-1",
+		ManagedCluster: &clusterv1.ManagedCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "cluster-1",
+				Labels: map[string]string{
+					"region": "eu-west-1",
+					"team":   "ignored",
+				},
+			},
+			Status: clusterv1.ManagedClusterStatus{
+				ClusterClaims: []clusterv1.ManagedClusterClaim{
+					{Name: "id.openshift.io", Value: "4f7e6b1c"},
+				},
+			},
+		},
+		AddOnDeploymentConfig: &addonapiv1alpha1.AddOnDeploymentConfig{
+			Spec: addonapiv1alpha1.AddOnDeploymentConfigSpec{
+				CustomizedVariables: []addonapiv1alpha1.CustomizedVariable{
+					{Name: "tracingClusterLabels", Value: "region, missing"},
+				},
+			},
+		},
+		OpenTelemetryCollector: &otelv1alpha1.OpenTelemetryCollector{
+			Spec: otelv1alpha1.OpenTelemetryCollectorSpec{
+				Config: validationConfig,
+			},
+		},
+	}
+
+	require.NoError(t, configureClusterAttributes(resources))
+
+	cfg, err := otelcol.ConfigFromString(resources.OpenTelemetryCollector.Spec.Config)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"key": "k8s.cluster.label.region", "value": "eu-west-1", "action": "upsert"},
+		map[string]interface{}{"key": "k8s.cluster.name", "value": "cluster-1", "action": "upsert"},
+		map[string]interface{}{"key": "k8s.cluster.uid", "value": "4f7e6b1c", "action": "upsert"},
+	}, cfg.Processors["resource/mcoa-cluster"]["attributes"])
+}
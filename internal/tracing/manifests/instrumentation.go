@@ -0,0 +1,132 @@
+package manifests
+
+import (
+	"fmt"
+	"strings"
+
+	otelv1alpha1 "github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	otlpGRPCPort = 4317
+	otlpHTTPPort = 4318
+
+	otlpEndpointEnvVar = "OTEL_EXPORTER_OTLP_ENDPOINT"
+
+	instrumentationName = "mcoa-instrumentation"
+
+	ReasonInstrumentationNamespacesFound    = "InstrumentationNamespacesFound"
+	ReasonInstrumentationNamespacesNotFound = "InstrumentationNamespacesNotFound"
+)
+
+// instrumentationNamespaces returns the namespaces that opted in to the
+// auto-instrumentation in the AddOnDeploymentConfig.
+func instrumentationNamespaces(resources Options) []string {
+	namespaces := []string{}
+	for _, ns := range strings.Split(addon.CustomizedVariable(resources.AddOnDeploymentConfig, instrumentationNamespacesKey), ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
+
+// useInstrumentation returns true when the Instrumentation template is
+// rendered in at least one namespace.
+func useInstrumentation(resources Options) bool {
+	return resources.Instrumentation != nil && len(instrumentationNamespaces(resources)) > 0
+}
+
+// buildInstrumentationSpec points the exporter of the Instrumentation template
+// to the collector deployed by the addon, the agent of the node of the
+// instrumented pod when agents are deployed.
+// The SDKs of Python, .NET and Go export with OTLP over HTTP, the other ones
+// with OTLP over gRPC.
+func buildInstrumentationSpec(resources Options) *otelv1alpha1.InstrumentationSpec {
+	spec := resources.Instrumentation.Spec.DeepCopy()
+
+	service := collectorService
+	if resources.AgentOpenTelemetryCollector != nil {
+		service = agentCollectorService
+	}
+
+	spec.Exporter.Endpoint = fmt.Sprintf("http://%s:%d", service, otlpGRPCPort)
+
+	httpEndpoint := corev1.EnvVar{
+		Name:  otlpEndpointEnvVar,
+		Value: fmt.Sprintf("http://%s:%d", service, otlpHTTPPort),
+	}
+	spec.Python.Env = setEnvVar(spec.Python.Env, httpEndpoint)
+	spec.DotNet.Env = setEnvVar(spec.DotNet.Env, httpEndpoint)
+	spec.Go.Env = setEnvVar(spec.Go.Env, httpEndpoint)
+
+	return spec
+}
+
+func setEnvVar(envs []corev1.EnvVar, env corev1.EnvVar) []corev1.EnvVar {
+	for i := range envs {
+		if envs[i].Name == env.Name {
+			envs[i] = env
+			return envs
+		}
+	}
+	return append(envs, env)
+}
+
+// MissingInstrumentationNamespaces returns the namespaces of the
+// Instrumentation that the work agent of the cluster couldn't apply because
+// they don't exist, as reported in the status of the addon ManifestWorks.
+func MissingInstrumentationNamespaces(works []workv1.ManifestWork, namespaces []string) []string {
+	missing := []string{}
+	for _, work := range works {
+		for _, manifest := range work.Status.ResourceStatus.Manifests {
+			resource := manifest.ResourceMeta
+			if resource.Group != otelv1alpha1.GroupVersion.Group || resource.Kind != "Instrumentation" || resource.Name != instrumentationName {
+				continue
+			}
+			if !contains(namespaces, resource.Namespace) || contains(missing, resource.Namespace) {
+				continue
+			}
+
+			applied := meta.FindStatusCondition(manifest.Conditions, workv1.ManifestApplied)
+			if applied != nil && applied.Status == metav1.ConditionFalse &&
+				strings.Contains(applied.Message, fmt.Sprintf("namespaces %q not found", resource.Namespace)) {
+				missing = append(missing, resource.Namespace)
+			}
+		}
+	}
+	return missing
+}
+
+// InstrumentationCondition returns the ManagedClusterAddOn condition
+// reporting the instrumentation namespaces that don't exist on the cluster.
+func InstrumentationCondition(missing []string) metav1.Condition {
+	if len(missing) == 0 {
+		return metav1.Condition{
+			Type:    ConditionTypeTracingInstrumentationDegraded,
+			Status:  metav1.ConditionFalse,
+			Reason:  ReasonInstrumentationNamespacesFound,
+			Message: "Instrumentation namespaces exist",
+		}
+	}
+	return metav1.Condition{
+		Type:    ConditionTypeTracingInstrumentationDegraded,
+		Status:  metav1.ConditionTrue,
+		Reason:  ReasonInstrumentationNamespacesNotFound,
+		Message: fmt.Sprintf("namespaces listed in %s don't exist: %s", instrumentationNamespacesKey, strings.Join(missing, ", ")),
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
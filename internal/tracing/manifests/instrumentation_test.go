@@ -0,0 +1,117 @@
+package manifests
+
+import (
+	"errors"
+	"testing"
+
+	otelv1alpha1 "github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	workv1 "open-cluster-management.io/api/work/v1"
+)
+
+const jaegerConfig = `
+receivers:
+  jaeger:
+exporters:
+  otlphttp:
+service:
+  pipelines:
+    traces:
+      receivers: [jaeger]
+      exporters: [otlphttp]
+`
+
+const grpcConfig = `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: 0.0.0.0:4317
+exporters:
+  otlphttp:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      exporters: [otlphttp]
+`
+
+func Test_ValidateOtelColConfig_Instrumentation(t *testing.T) {
+	resources := Options{
+		Instrumentation: &otelv1alpha1.Instrumentation{},
+		AddOnDeploymentConfig: &addonapiv1alpha1.AddOnDeploymentConfig{
+			Spec: addonapiv1alpha1.AddOnDeploymentConfigSpec{
+				CustomizedVariables: []addonapiv1alpha1.CustomizedVariable{
+					{Name: "tracingInstrumentationNamespaces", Value: "app-1"},
+				},
+			},
+		},
+	}
+
+	err := validateOtelColConfig(jaegerConfig, resources)
+
+	var verr *addon.ValidationError
+	require.True(t, errors.As(err, &verr))
+	require.Equal(t, []string{
+		"instrumentation requires an otlp receiver in the collector with the grpc protocol on port 4317",
+		"instrumentation requires an otlp receiver in the collector with the http protocol on port 4318",
+	}, verr.Findings)
+
+	err = validateOtelColConfig(grpcConfig, resources)
+	require.True(t, errors.As(err, &verr))
+	require.Equal(t, []string{"instrumentation requires an otlp receiver in the collector with the http protocol on port 4318"}, verr.Findings)
+
+	// Without namespaces opting in the Instrumentation isn't rendered
+	resources.AddOnDeploymentConfig = nil
+	require.NoError(t, validateOtelColConfig(jaegerConfig, resources))
+}
+
+func Test_MissingInstrumentationNamespaces(t *testing.T) {
+	instrumentation := func(namespace, message string) workv1.ManifestCondition {
+		status := metav1.ConditionTrue
+		if message != "" {
+			status = metav1.ConditionFalse
+		}
+		return workv1.ManifestCondition{
+			ResourceMeta: workv1.ManifestResourceMeta{
+				Group:     "opentelemetry.io",
+				Kind:      "Instrumentation",
+				Name:      "mcoa-instrumentation",
+				Namespace: namespace,
+			},
+			Conditions: []metav1.Condition{
+				{Type: workv1.ManifestApplied, Status: status, Message: message},
+			},
+		}
+	}
+
+	works := []workv1.ManifestWork{
+		{
+			Status: workv1.ManifestWorkStatus{
+				ResourceStatus: workv1.ManifestResourceStatus{
+					Manifests: []workv1.ManifestCondition{
+						instrumentation("app-1", ""),
+						instrumentation("app-2", `Failed to apply manifest: namespaces "app-2" not found`),
+						instrumentation("app-3", "Failed to apply manifest: the server was unable to return a response in the time allotted"),
+						// No longer listed in tracingInstrumentationNamespaces
+						instrumentation("app-4", `Failed to apply manifest: namespaces "app-4" not found`),
+					},
+				},
+			},
+		},
+	}
+
+	missing := MissingInstrumentationNamespaces(works, []string{"app-1", "app-2", "app-3"})
+	require.Equal(t, []string{"app-2"}, missing)
+
+	condition := InstrumentationCondition(missing)
+	require.Equal(t, metav1.ConditionTrue, condition.Status)
+	require.Equal(t, ReasonInstrumentationNamespacesNotFound, condition.Reason)
+	require.Equal(t, "namespaces listed in tracingInstrumentationNamespaces don't exist: app-2", condition.Message)
+
+	condition = InstrumentationCondition(MissingInstrumentationNamespaces(nil, []string{"app-1"}))
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+}
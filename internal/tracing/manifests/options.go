@@ -2,14 +2,38 @@ package manifests
 
 import (
 	otelv1alpha1 "github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/patch"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 )
 
 type Options struct {
 	ClusterName            string
+	ManagedCluster         *clusterv1.ManagedCluster
 	Secrets                []corev1.Secret
 	ConfigMaps             []corev1.ConfigMap
 	OpenTelemetryCollector *otelv1alpha1.OpenTelemetryCollector
-	AddOnDeploymentConfig  *addonapiv1alpha1.AddOnDeploymentConfig
+	// OpenTelemetryCollectorV1Beta1 is the template when the v1beta1 API is
+	// used, OpenTelemetryCollector is then its v1alpha1 conversion
+	OpenTelemetryCollectorV1Beta1 *unstructured.Unstructured
+	// AgentOpenTelemetryCollector is the template of the optional agent
+	// collectors, running on every node and forwarding the spans to the
+	// OpenTelemetryCollector acting as gateway
+	AgentOpenTelemetryCollector        *otelv1alpha1.OpenTelemetryCollector
+	AgentOpenTelemetryCollectorV1Beta1 *unstructured.Unstructured
+	// Instrumentation is the template of the Instrumentation rendered in the
+	// namespaces that opted in to the auto-instrumentation
+	Instrumentation       *otelv1alpha1.Instrumentation
+	CABundles             authentication.CABundles
+	TempoStackGateway     *TempoStackGateway
+	AddOnDeploymentConfig *addonapiv1alpha1.AddOnDeploymentConfig
+	// Patches are applied to the templated OpenTelemetryCollector, ordered
+	// from the broadest to the most specific
+	Patches []patch.Patch
+	// Sampling are the ConfigMaps setting the sampling policy of the cluster,
+	// ordered from the broadest to the most specific
+	Sampling []corev1.ConfigMap
 }
@@ -0,0 +1,144 @@
+package otelcol
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	// agentExporter forwards the spans received by the agent collectors to
+	// the gateway collector
+	agentExporter = "otlp/mcoa-gateway"
+	// agentLoadBalancingExporter forwards the spans received by the agent
+	// collectors to the gateway collector replicas, routed by trace ID
+	agentLoadBalancingExporter = "loadbalancing/mcoa-gateway"
+
+	otlpReceiver = "otlp"
+)
+
+// Protocols of the otlp receiver
+const (
+	OTLPGRPC = "grpc"
+	OTLPHTTP = "http"
+)
+
+// otlpDefaultPorts are the ports the otlp receiver listens on when the
+// protocol doesn't set an endpoint
+var otlpDefaultPorts = map[string]int{
+	OTLPGRPC: 4317,
+	OTLPHTTP: 4318,
+}
+
+// ConfigureAgentExporter adds to every traces pipeline of an agent collector
+// an exporter forwarding the spans to the gateway collector listening on
+// endpoint. Both collectors run in the same cluster and the exporter doesn't
+// use TLS.
+func ConfigureAgentExporter(cfg *Config, endpoint string) {
+	configureAgentExporter(cfg, agentExporter, Component{
+		"endpoint": endpoint,
+		"tls": map[string]interface{}{
+			"insecure": true,
+		},
+	})
+}
+
+// ConfigureAgentLoadBalancingExporter adds to every traces pipeline of an
+// agent collector an exporter routing the spans by trace ID to the gateway
+// collector replicas resolved from the headless service at hostname, so that
+// all the spans of a trace reach the same replica as tail sampling requires.
+func ConfigureAgentLoadBalancingExporter(cfg *Config, hostname string, port int) {
+	configureAgentExporter(cfg, agentLoadBalancingExporter, Component{
+		"routing_key": "traceID",
+		"protocol": map[string]interface{}{
+			"otlp": map[string]interface{}{
+				"tls": map[string]interface{}{
+					"insecure": true,
+				},
+			},
+		},
+		"resolver": map[string]interface{}{
+			"dns": map[string]interface{}{
+				"hostname": hostname,
+				"port":     strconv.Itoa(port),
+			},
+		},
+	})
+}
+
+func configureAgentExporter(cfg *Config, exporter string, settings Component) {
+	configured := false
+	for name, pipeline := range cfg.Service.Pipelines {
+		if componentType(name) != tracesPipeline || pipeline == nil {
+			continue
+		}
+		if !contains(pipeline.Exporters, exporter) {
+			pipeline.Exporters = append(pipeline.Exporters, exporter)
+		}
+		configured = true
+	}
+	if !configured {
+		return
+	}
+
+	if cfg.Exporters == nil {
+		cfg.Exporters = map[string]Component{}
+	}
+	cfg.Exporters[exporter] = settings
+}
+
+// HasOTLPReceiver returns true when the configuration defines an otlp
+// receiver.
+func HasOTLPReceiver(cfg *Config) bool {
+	for name := range cfg.Receivers {
+		if componentType(name) == otlpReceiver {
+			return true
+		}
+	}
+	return false
+}
+
+// HasOTLPProtocol returns true when an otlp receiver of the configuration
+// enables the protocol and listens on port, the default port of the protocol
+// when it doesn't set an endpoint.
+func HasOTLPProtocol(cfg *Config, protocol string, port int) bool {
+	for name, receiver := range cfg.Receivers {
+		if componentType(name) != otlpReceiver || receiver == nil {
+			continue
+		}
+		protocols, ok := receiver["protocols"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		settings, ok := protocols[protocol]
+		if !ok {
+			continue
+		}
+
+		endpoint := ""
+		if settings, ok := settings.(map[string]interface{}); ok {
+			endpoint, _ = settings["endpoint"].(string)
+		}
+		if endpointPort(endpoint, otlpDefaultPorts[protocol]) == port {
+			return true
+		}
+	}
+	return false
+}
+
+// endpointPort returns the port of a host:port endpoint, the host can
+// reference an environment variable, e.g. ${env:MY_POD_IP}:4317. It returns
+// defaultPort for an empty endpoint and 0 when the port can't be parsed.
+func endpointPort(endpoint string, defaultPort int) int {
+	if endpoint == "" {
+		return defaultPort
+	}
+	i := strings.LastIndex(endpoint, ":")
+	if i < 0 {
+		return 0
+	}
+	port, err := strconv.Atoi(endpoint[i+1:])
+	if err != nil {
+		return 0
+	}
+	return port
+}
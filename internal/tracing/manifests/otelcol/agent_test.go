@@ -0,0 +1,154 @@
+package otelcol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConfigureAgentExporter(t *testing.T) {
+	cfg, err := ConfigFromString(`
+receivers:
+  otlp:
+  jaeger:
+exporters:
+  debug:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp, jaeger]
+      exporters: [debug]
+    traces/node:
+      receivers: [otlp]
+`)
+	require.NoError(t, err)
+	require.True(t, HasOTLPReceiver(cfg))
+
+	for i := 0; i < 2; i++ {
+		ConfigureAgentExporter(cfg, "spoke-otelcol-collector.spoke-otelcol.svc:4317")
+	}
+
+	require.Equal(t, []string{"debug", "otlp/mcoa-gateway"}, cfg.Service.Pipelines["traces"].Exporters)
+	require.Equal(t, []string{"otlp/mcoa-gateway"}, cfg.Service.Pipelines["traces/node"].Exporters)
+	require.Equal(t, Component{
+		"endpoint": "spoke-otelcol-collector.spoke-otelcol.svc:4317",
+		"tls": map[string]interface{}{
+			"insecure": true,
+		},
+	}, cfg.Exporters["otlp/mcoa-gateway"])
+	require.Empty(t, Validate(cfg))
+}
+
+func Test_ConfigureAgentLoadBalancingExporter(t *testing.T) {
+	cfg, err := ConfigFromString(`
+receivers:
+  otlp:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+`)
+	require.NoError(t, err)
+
+	ConfigureAgentLoadBalancingExporter(cfg, "spoke-otelcol-collector-headless.spoke-otelcol.svc", 4317)
+
+	require.Equal(t, []string{"loadbalancing/mcoa-gateway"}, cfg.Service.Pipelines["traces"].Exporters)
+	require.Equal(t, "traceID", cfg.Exporters["loadbalancing/mcoa-gateway"]["routing_key"])
+	require.Equal(t, map[string]interface{}{
+		"dns": map[string]interface{}{
+			"hostname": "spoke-otelcol-collector-headless.spoke-otelcol.svc",
+			"port":     "4317",
+		},
+	}, cfg.Exporters["loadbalancing/mcoa-gateway"]["resolver"])
+	require.Empty(t, Validate(cfg))
+}
+
+func Test_HasOTLPProtocol(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		config   string
+		protocol string
+		port     int
+		want     bool
+	}{
+		{
+			name: "default endpoint",
+			config: `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+      http:
+`,
+			protocol: OTLPHTTP,
+			port:     4318,
+			want:     true,
+		},
+		{
+			name: "endpoint with environment variable",
+			config: `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: ${env:MY_POD_IP}:4317
+`,
+			protocol: OTLPGRPC,
+			port:     4317,
+			want:     true,
+		},
+		{
+			name: "named receiver",
+			config: `
+receivers:
+  otlp/apps:
+    protocols:
+      http:
+        endpoint: 0.0.0.0:4318
+`,
+			protocol: OTLPHTTP,
+			port:     4318,
+			want:     true,
+		},
+		{
+			name: "other port",
+			config: `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: 0.0.0.0:14317
+`,
+			protocol: OTLPGRPC,
+			port:     4317,
+		},
+		{
+			name: "protocol not enabled",
+			config: `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+`,
+			protocol: OTLPHTTP,
+			port:     4318,
+		},
+		{
+			name: "no otlp receiver",
+			config: `
+receivers:
+  jaeger:
+    protocols:
+      grpc:
+`,
+			protocol: OTLPGRPC,
+			port:     4317,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := ConfigFromString(tc.config)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, HasOTLPProtocol(cfg, tc.protocol, tc.port))
+		})
+	}
+}
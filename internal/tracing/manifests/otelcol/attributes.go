@@ -0,0 +1,161 @@
+package otelcol
+
+import (
+	"sort"
+
+	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ClusterNameAttribute is the resource attribute holding the name of the
+	// managed cluster
+	ClusterNameAttribute = "k8s.cluster.name"
+	// ClusterUIDAttribute is the resource attribute holding the ID claimed by
+	// the managed cluster
+	ClusterUIDAttribute = "k8s.cluster.uid"
+	// ClusterLabelAttributePrefix prefixes the resource attributes holding the
+	// labels of the ManagedCluster
+	ClusterLabelAttributePrefix = "k8s.cluster.label."
+
+	clusterResourceProcessor = "resource/mcoa-cluster"
+	k8sAttributesProcessor   = "k8sattributes/mcoa"
+	batchProcessor           = "batch"
+
+	tracesPipeline = "traces"
+
+	// nodeNameEnvVar exposes to the agent collectors the name of the node
+	// they run on
+	nodeNameEnvVar = "KUBE_NODE_NAME"
+)
+
+// ConfigureK8sAttributes adds to every traces pipeline a k8sattributes
+// processor, adding the attributes of the pod sending the spans. It runs
+// after the memory_limiter processors of the template and before its other
+// processors. The pod is identified by the address of the connection so it
+// must run in the collector receiving the spans from the applications.
+func ConfigureK8sAttributes(cfg *Config) {
+	if !configureTracesPipelines(cfg, func(processors []string) []string {
+		filtered := removeProcessor(processors, k8sAttributesProcessor)
+		return insertAfter(filtered, k8sAttributesProcessor, memoryLimiters(filtered)...)
+	}) {
+		return
+	}
+
+	if cfg.Processors == nil {
+		cfg.Processors = map[string]Component{}
+	}
+	cfg.Processors[k8sAttributesProcessor] = Component{}
+}
+
+// ConfigureK8sAttributesNodeFilter restricts the k8sattributes processor of an
+// agent collector to the pods of the node it runs on, instead of watching all
+// the pods of the cluster from every node. The node name is exposed to the
+// collector with the downward API.
+func ConfigureK8sAttributesNodeFilter(spec *v1alpha1.OpenTelemetryCollectorSpec, cfg *Config) {
+	processor, ok := cfg.Processors[k8sAttributesProcessor]
+	if !ok {
+		return
+	}
+	if processor == nil {
+		processor = Component{}
+		cfg.Processors[k8sAttributesProcessor] = processor
+	}
+	processor.Map("filter")["node_from_env_var"] = nodeNameEnvVar
+
+	configureEnvVar(spec, corev1.EnvVar{
+		Name: nodeNameEnvVar,
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{FieldPath: "spec.nodeName"},
+		},
+	})
+}
+
+// ConfigureClusterAttributes adds to every traces pipeline a resource
+// processor setting the attributes of the cluster. It runs after the
+// memory_limiter processors of the template and the k8sattributes processor,
+// if any, and before the other processors of the template. Attributes already
+// set on the spans are replaced.
+func ConfigureClusterAttributes(cfg *Config, attributes map[string]string) {
+	if !configureTracesPipelines(cfg, func(processors []string) []string {
+		filtered := removeProcessor(processors, clusterResourceProcessor)
+		after := append(memoryLimiters(filtered), k8sAttributesProcessor)
+		return insertAfter(filtered, clusterResourceProcessor, after...)
+	}) {
+		return
+	}
+
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	actions := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		actions = append(actions, map[string]interface{}{
+			"key":    key,
+			"value":  attributes[key],
+			"action": "upsert",
+		})
+	}
+
+	if cfg.Processors == nil {
+		cfg.Processors = map[string]Component{}
+	}
+	cfg.Processors[clusterResourceProcessor] = Component{
+		"attributes": actions,
+	}
+}
+
+// configureTracesPipelines replaces the processors of every traces pipeline
+// with the result of configure. Returns false when there are no traces
+// pipelines.
+func configureTracesPipelines(cfg *Config, configure func(processors []string) []string) bool {
+	configured := false
+	for name, pipeline := range cfg.Service.Pipelines {
+		if componentType(name) != tracesPipeline || pipeline == nil {
+			continue
+		}
+		pipeline.Processors = configure(pipeline.Processors)
+		configured = true
+	}
+	return configured
+}
+
+// removeProcessor returns the list without the processor.
+func removeProcessor(list []string, processor string) []string {
+	result := []string{}
+	for _, name := range list {
+		if name != processor {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// memoryLimiters returns the memory_limiter processors of the list that run
+// before its first batch processor. The processors of the addon run after
+// them so that they still protect the collector, but not after batching since
+// the k8sattributes processor needs the connection of the request.
+func memoryLimiters(list []string) []string {
+	result := []string{}
+	for _, name := range list {
+		switch componentType(name) {
+		case batchProcessor:
+			return result
+		case memoryLimiterProcessor:
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package otelcol
+
+import (
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_ConfigureClusterAttributes(t *testing.T) {
+	cfg, err := ConfigFromString(`
+receivers:
+  otlp:
+processors:
+  batch:
+exporters:
+  otlphttp:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [otlphttp]
+    traces/internal:
+      receivers: [otlp]
+      exporters: [otlphttp]
+    metrics:
+      receivers: [otlp]
+      exporters: [otlphttp]
+`)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		ConfigureClusterAttributes(cfg, map[string]string{ClusterNameAttribute: "cluster-1"})
+		ConfigureK8sAttributes(cfg)
+	}
+
+	require.Equal(t, []string{"k8sattributes/mcoa", "resource/mcoa-cluster", "batch"}, cfg.Service.Pipelines["traces"].Processors)
+	require.Equal(t, []string{"k8sattributes/mcoa", "resource/mcoa-cluster"}, cfg.Service.Pipelines["traces/internal"].Processors)
+	require.Empty(t, cfg.Service.Pipelines["metrics"].Processors)
+	require.Equal(t, Component{
+		"attributes": []interface{}{
+			map[string]interface{}{"key": "k8s.cluster.name", "value": "cluster-1", "action": "upsert"},
+		},
+	}, cfg.Processors["resource/mcoa-cluster"])
+	require.Empty(t, Validate(cfg))
+
+	// Agent collectors add the pod attributes, gateways only the cluster ones
+	cfg, err = ConfigFromString(`
+receivers:
+  otlp:
+exporters:
+  otlphttp:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      exporters: [otlphttp]
+`)
+	require.NoError(t, err)
+
+	ConfigureClusterAttributes(cfg, map[string]string{ClusterNameAttribute: "cluster-1"})
+	require.Equal(t, []string{"resource/mcoa-cluster"}, cfg.Service.Pipelines["traces"].Processors)
+	require.NotContains(t, cfg.Processors, "k8sattributes/mcoa")
+}
+
+func Test_ConfigureClusterAttributes_MemoryLimiter(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		processors []string
+		expected   []string
+	}{
+		{
+			name:       "after memory_limiter",
+			processors: []string{"memory_limiter", "batch"},
+			expected:   []string{"memory_limiter", "k8sattributes/mcoa", "resource/mcoa-cluster", "batch"},
+		},
+		{
+			name:       "after named memory_limiters",
+			processors: []string{"memory_limiter/a", "memory_limiter/b", "attributes", "batch"},
+			expected:   []string{"memory_limiter/a", "memory_limiter/b", "k8sattributes/mcoa", "resource/mcoa-cluster", "attributes", "batch"},
+		},
+		{
+			name:       "before batch",
+			processors: []string{"batch", "memory_limiter"},
+			expected:   []string{"k8sattributes/mcoa", "resource/mcoa-cluster", "batch", "memory_limiter"},
+		},
+		{
+			name:       "memory_limiter without batch",
+			processors: []string{"memory_limiter", "attributes"},
+			expected:   []string{"memory_limiter", "k8sattributes/mcoa", "resource/mcoa-cluster", "attributes"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{
+				Service: Service{
+					Pipelines: map[string]*Pipeline{
+						"traces": {Processors: tc.processors},
+					},
+				},
+			}
+
+			for i := 0; i < 2; i++ {
+				ConfigureClusterAttributes(cfg, map[string]string{ClusterNameAttribute: "cluster-1"})
+				ConfigureK8sAttributes(cfg)
+			}
+			require.Equal(t, tc.expected, cfg.Service.Pipelines["traces"].Processors)
+		})
+	}
+}
+
+func Test_ConfigureClusterAttributes_NoTracesPipeline(t *testing.T) {
+	cfg := &Config{}
+
+	ConfigureClusterAttributes(cfg, map[string]string{ClusterNameAttribute: "cluster-1"})
+	ConfigureK8sAttributes(cfg)
+	require.Empty(t, cfg.Processors)
+}
+
+func Test_ConfigureK8sAttributesNodeFilter(t *testing.T) {
+	cfg, err := ConfigFromString(`
+receivers:
+  otlp:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+`)
+	require.NoError(t, err)
+
+	spec := &v1alpha1.OpenTelemetryCollectorSpec{}
+	ConfigureK8sAttributes(cfg)
+	ConfigureK8sAttributesNodeFilter(spec, cfg)
+
+	require.Equal(t, Component{
+		"filter": map[string]interface{}{"node_from_env_var": "KUBE_NODE_NAME"},
+	}, cfg.Processors["k8sattributes/mcoa"])
+	require.Equal(t, []corev1.EnvVar{
+		{
+			Name: "KUBE_NODE_NAME",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "spec.nodeName"},
+			},
+		},
+	}, spec.Env)
+}
@@ -5,11 +5,115 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-func ConfigFromString(configStr string) (map[string]interface{}, error) {
-	config := make(map[string]interface{})
-	if err := yaml.Unmarshal([]byte(configStr), &config); err != nil {
-		return nil, kverrors.New("couldn't parse the opentelemetry-collector configuration")
+// Config is the configuration of an OpenTelemetry Collector. Components keep
+// their settings as generic maps since each of them defines its own, settings
+// unknown to this model are kept in Other to be written back unchanged.
+type Config struct {
+	Receivers  map[string]Component `yaml:"receivers,omitempty"`
+	Processors map[string]Component `yaml:"processors,omitempty"`
+	Exporters  map[string]Component `yaml:"exporters,omitempty"`
+	Extensions map[string]Component `yaml:"extensions,omitempty"`
+	Connectors map[string]Component `yaml:"connectors,omitempty"`
+	Service    Service              `yaml:"service"`
+
+	Other map[string]interface{} `yaml:",inline"`
+}
+
+// Component is the configuration of a receiver, processor, exporter,
+// extension or connector. It's nil for components using their defaults.
+type Component map[string]interface{}
+
+// Service defines the extensions enabled and the pipelines built from the
+// components of the configuration.
+type Service struct {
+	Extensions []string             `yaml:"extensions,omitempty"`
+	Pipelines  map[string]*Pipeline `yaml:"pipelines,omitempty"`
+
+	Other map[string]interface{} `yaml:",inline"`
+}
+
+// Pipeline lists the components of a pipeline by their <type>[/<name>] ID
+type Pipeline struct {
+	Receivers  []string `yaml:"receivers,omitempty"`
+	Processors []string `yaml:"processors,omitempty"`
+	Exporters  []string `yaml:"exporters,omitempty"`
+
+	Other map[string]interface{} `yaml:",inline"`
+}
+
+// ConfigFromString parses the configuration of an OpenTelemetry Collector
+func ConfigFromString(configStr string) (*Config, error) {
+	config := &Config{}
+	if err := yaml.Unmarshal([]byte(configStr), config); err != nil {
+		return nil, kverrors.Wrap(err, "couldn't parse the opentelemetry-collector configuration")
 	}
 
 	return config, nil
 }
+
+// ConfigToString serializes the configuration of an OpenTelemetry Collector
+func ConfigToString(config *Config) (string, error) {
+	b, err := yaml.Marshal(config)
+	if err != nil {
+		return "", kverrors.Wrap(err, "error while marshaling OTEL Configuration")
+	}
+	return string(b), nil
+}
+
+// ConfigToObject returns the configuration as a generic object, as it would
+// be decoded from its YAML serialization
+func ConfigToObject(config *Config) (map[string]interface{}, error) {
+	configStr, err := ConfigToString(config)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(configStr), &obj); err != nil {
+		return nil, kverrors.Wrap(err, "couldn't parse the opentelemetry-collector configuration")
+	}
+	return obj, nil
+}
+
+// ConfigFromObject parses the configuration of an OpenTelemetry Collector
+// given as a generic object
+func ConfigFromObject(obj interface{}) (*Config, error) {
+	b, err := yaml.Marshal(obj)
+	if err != nil {
+		return nil, kverrors.Wrap(err, "error while marshaling OTEL Configuration")
+	}
+	return ConfigFromString(string(b))
+}
+
+// UnmarshalYAML decodes the settings as a generic map, otherwise yaml.v3
+// decodes the nested maps as Components too.
+func (c *Component) UnmarshalYAML(value *yaml.Node) error {
+	var settings map[string]interface{}
+	if err := value.Decode(&settings); err != nil {
+		return err
+	}
+	*c = settings
+	return nil
+}
+
+// Map returns the map stored in the key of the component settings, creating
+// it when it doesn't exist or isn't a map.
+func (c Component) Map(key string) map[string]interface{} {
+	m, ok := c[key].(map[string]interface{})
+	if !ok {
+		m = map[string]interface{}{}
+		c[key] = m
+	}
+	return m
+}
+
+// exporter returns the settings of the exporter, initialized when the
+// exporter uses its defaults so that they can be modified.
+func (c *Config) exporter(name string) Component {
+	exporter := c.Exporters[name]
+	if exporter == nil {
+		exporter = Component{}
+		c.Exporters[name] = exporter
+	}
+	return exporter
+}
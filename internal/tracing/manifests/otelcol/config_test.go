@@ -0,0 +1,124 @@
+package otelcol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const fullConfig = `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: 0.0.0.0:4317
+      http:
+processors:
+  batch:
+    timeout: 5s
+    send_batch_size: 1000
+exporters:
+  otlphttp:
+    endpoint: https://tempo
+    headers:
+      x-scope-orgid: cluster-1
+  debug:
+extensions:
+  health_check:
+connectors:
+  spanmetrics:
+    dimensions:
+      - name: http.method
+service:
+  extensions: [health_check]
+  telemetry:
+    logs:
+      level: debug
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [otlphttp, spanmetrics]
+    metrics:
+      receivers: [spanmetrics]
+      exporters: [debug]
+x-unknown:
+  key: value
+`
+
+func Test_ConfigFromString(t *testing.T) {
+	cfg, err := ConfigFromString(fullConfig)
+	require.NoError(t, err)
+
+	require.Equal(t, Component{"timeout": "5s", "send_batch_size": 1000}, cfg.Processors["batch"])
+	require.Equal(t, map[string]interface{}{"x-scope-orgid": "cluster-1"}, cfg.Exporters["otlphttp"]["headers"])
+	require.Contains(t, cfg.Exporters, "debug")
+	require.Nil(t, cfg.Exporters["debug"])
+	require.Contains(t, cfg.Extensions, "health_check")
+	require.Contains(t, cfg.Connectors, "spanmetrics")
+	require.Equal(t, []string{"health_check"}, cfg.Service.Extensions)
+	require.Equal(t, &Pipeline{
+		Receivers:  []string{"otlp"},
+		Processors: []string{"batch"},
+		Exporters:  []string{"otlphttp", "spanmetrics"},
+	}, cfg.Service.Pipelines["traces"])
+	require.Equal(t, map[string]interface{}{"logs": map[string]interface{}{"level": "debug"}}, cfg.Service.Other["telemetry"])
+	require.Equal(t, map[string]interface{}{"key": "value"}, cfg.Other["x-unknown"])
+
+	_, err = ConfigFromString("receivers: [")
+	require.Error(t, err)
+}
+
+func Test_ConfigToString_RoundTrip(t *testing.T) {
+	cfg, err := ConfigFromString(fullConfig)
+	require.NoError(t, err)
+
+	out, err := ConfigToString(cfg)
+	require.NoError(t, err)
+
+	var expected, actual map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(fullConfig), &expected))
+	require.NoError(t, yaml.Unmarshal([]byte(out), &actual))
+
+	// Components using their defaults are written as empty maps
+	for _, path := range [][]string{
+		{"receivers", "otlp", "protocols", "http"},
+		{"exporters", "debug"},
+		{"extensions", "health_check"},
+	} {
+		require.Empty(t, lookup(t, actual, path...))
+	}
+	expected["exporters"].(map[string]interface{})["debug"] = map[string]interface{}{}
+	expected["extensions"].(map[string]interface{})["health_check"] = map[string]interface{}{}
+
+	require.Equal(t, expected, actual)
+}
+
+func Test_ConfigToObject_RoundTrip(t *testing.T) {
+	cfg, err := ConfigFromString(fullConfig)
+	require.NoError(t, err)
+
+	obj, err := ConfigToObject(cfg)
+	require.NoError(t, err)
+	require.Contains(t, obj, "receivers")
+
+	fromObj, err := ConfigFromObject(obj)
+	require.NoError(t, err)
+
+	expected, err := ConfigToString(cfg)
+	require.NoError(t, err)
+	actual, err := ConfigToString(fromObj)
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+}
+
+func lookup(t *testing.T, m map[string]interface{}, path ...string) interface{} {
+	var value interface{} = m
+	for _, key := range path {
+		node, ok := value.(map[string]interface{})
+		require.True(t, ok, "%v is not a map", path)
+		value = node[key]
+	}
+	return value
+}
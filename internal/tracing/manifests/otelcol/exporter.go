@@ -1,42 +1,41 @@
 package otelcol
 
 import (
-	"fmt"
+	"path"
 
 	"github.com/ViaQ/logerr/v2/kverrors"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
 	corev1 "k8s.io/api/core/v1"
 )
 
-func ConfigureExportersSecrets(cfg map[string]interface{}, secret corev1.Secret, annotation string) error {
+// ConfigureExportersSecrets configures the TLS settings of the exporter
+// referenced by the secret annotation with the keys of the secret mounted in
+// folder. When caFile is empty the CA is read from the "ca-bundle.crt" key of
+// the secret. Secrets holding only headers don't configure TLS.
+func ConfigureExportersSecrets(cfg *Config, secret corev1.Secret, folder, caFile string, annotation string) error {
 	otelExporterName, ok := secret.Annotations[annotation]
 	if !ok {
 		return nil
 	}
 
+	if _, ok := secret.Data["tls.crt"]; !ok && len(headerKeys(secret.Data)) > 0 {
+		return nil
+	}
+
 	exporters, err := getExporters(cfg)
 	if err != nil {
 		return err
 	}
 
-	for exporterName, config := range exporters {
-		if otelExporterName != exporterName {
-			continue
-		}
-		var configMap map[interface{}]interface{}
-		if config == nil {
-			configMap = make(map[interface{}]interface{})
-			exporters[otelExporterName] = configMap
-		} else {
-			configMap = config.(map[interface{}]interface{})
-		}
-
-		configureExporterSecrets(&configMap, secret)
-
+	if _, ok := exporters[otelExporterName]; !ok {
+		return nil
 	}
+
+	configureExporterSecrets(cfg.exporter(otelExporterName), folder, caFile)
 	return nil
 }
 
-func ConfigureExporters(cfg map[string]interface{}, cm corev1.ConfigMap, clusterName string, annotation string) error {
+func ConfigureExporters(cfg *Config, cm corev1.ConfigMap, values addon.ClusterValues, annotation string) error {
 	otelExporterName, ok := cm.Annotations[annotation]
 	if !ok {
 		return nil
@@ -47,50 +46,37 @@ func ConfigureExporters(cfg map[string]interface{}, cm corev1.ConfigMap, cluster
 		return err
 	}
 
-	for exporterName, config := range exporters {
-		if otelExporterName != exporterName {
-			continue
-		}
-		var exporterConfig map[string]interface{}
-		if config == nil {
-			exporterConfig = make(map[string]interface{})
-			exporters[otelExporterName] = exporterConfig
-		} else {
-			exporterConfig = config.(map[string]interface{})
-		}
-
-		err := configureExporterEndpoint(exporterConfig, cm)
-		if err != nil {
-			return err
-		}
+	if _, ok := exporters[otelExporterName]; !ok {
+		return nil
+	}
 
-		configureTenant(exporterConfig, clusterName)
+	exporter := cfg.exporter(otelExporterName)
+	if err := configureExporterEndpoint(exporter, cm); err != nil {
+		return err
 	}
-	return nil
+	return configureHeaders(exporter, cm, values)
 }
 
-func getExporters(cfg map[string]interface{}) (map[string]interface{}, error) {
-	exportersField, ok := cfg["exporters"]
-	if !ok {
+func getExporters(cfg *Config) (map[string]Component, error) {
+	if cfg.Exporters == nil {
 		return nil, kverrors.New("no exporters available as part of the configuration")
 	}
-
-	exporters := exportersField.(map[string]interface{})
-	return exporters, nil
+	return cfg.Exporters, nil
 }
 
-func configureExporterSecrets(exporter *map[interface{}]interface{}, secret corev1.Secret) {
-	certConfig := make(map[string]interface{})
-	folder := fmt.Sprintf("/%s", secret.Name)
-	certConfig["insecure"] = false
-	certConfig["cert_file"] = fmt.Sprintf("%s/tls.crt", folder)
-	certConfig["key_file"] = fmt.Sprintf("%s/tls.key", folder)
-	certConfig["ca_file"] = fmt.Sprintf("%s/ca-bundle.crt", folder)
+func configureExporterSecrets(exporter Component, folder, caFile string) {
+	if caFile == "" {
+		caFile = path.Join(folder, "ca-bundle.crt")
+	}
 
-	(*exporter)["tls"] = certConfig
+	// Other TLS settings of the template are kept
+	tls := exporter.Map("tls")
+	tls["cert_file"] = path.Join(folder, "tls.crt")
+	tls["key_file"] = path.Join(folder, "tls.key")
+	tls["ca_file"] = caFile
 }
 
-func configureExporterEndpoint(exporter map[string]interface{}, cm corev1.ConfigMap) error {
+func configureExporterEndpoint(exporter Component, cm corev1.ConfigMap) error {
 	url := cm.Data["endpoint"]
 	if url == "" {
 		return kverrors.New("no value for 'endpoint' in configmap", "name", cm.Name)
@@ -98,9 +84,3 @@ func configureExporterEndpoint(exporter map[string]interface{}, cm corev1.Config
 	exporter["endpoint"] = url
 	return nil
 }
-
-func configureTenant(exporter map[string]interface{}, clusterName string) {
-	headers := make(map[string]string)
-	headers["x-scope-orgid"] = clusterName
-	exporter["headers"] = headers
-}
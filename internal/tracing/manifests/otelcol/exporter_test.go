@@ -4,6 +4,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -33,12 +34,9 @@ func Test_ConfigureExportersSecrets(t *testing.T) {
 		},
 	}
 
-	err = ConfigureExportersSecrets(cfg, secret, annotation)
+	err = ConfigureExportersSecrets(cfg, secret, "/tracing-otlphttp-auth", "", annotation)
 	require.NoError(t, err)
-
-	exportersField := cfg["exporters"]
-	exporters := exportersField.(map[string]interface{})
-	require.Nil(t, exporters["debug"])
+	require.Nil(t, cfg.Exporters["debug"])
 
 	b, err = os.ReadFile("./test_data/basic_otelhttp.yaml")
 	require.NoError(t, err)
@@ -46,14 +44,13 @@ func Test_ConfigureExportersSecrets(t *testing.T) {
 	cfg, err = ConfigFromString(otelColConfig)
 	require.NoError(t, err)
 
-	err = ConfigureExportersSecrets(cfg, secret, annotation)
+	err = ConfigureExportersSecrets(cfg, secret, "/tracing-otlphttp-auth", "", annotation)
 	require.NoError(t, err)
-
-	exportersField = cfg["exporters"]
-	exporters = exportersField.(map[string]interface{})
-	otlphttpField := exporters["otlphttp"]
-	otlphttp := otlphttpField.(map[interface{}]interface{})
-	require.NotNil(t, otlphttp["tls"])
+	require.Equal(t, map[string]interface{}{
+		"cert_file": "/tracing-otlphttp-auth/tls.crt",
+		"key_file":  "/tracing-otlphttp-auth/tls.key",
+		"ca_file":   "/tracing-otlphttp-auth/ca-bundle.crt",
+	}, cfg.Exporters["otlphttp"]["tls"])
 }
 
 func Test_ConfigureExportersEndpoints(t *testing.T) {
@@ -79,12 +76,9 @@ func Test_ConfigureExportersEndpoints(t *testing.T) {
 		},
 	}
 
-	err = ConfigureExporters(cfg, cm, "cluster", annotation)
+	err = ConfigureExporters(cfg, cm, addon.NewClusterValues("cluster", nil), annotation)
 	require.NoError(t, err)
-
-	exportersField := cfg["exporters"]
-	exporters := exportersField.(map[string]interface{})
-	require.Nil(t, exporters["debug"])
+	require.Nil(t, cfg.Exporters["debug"])
 
 	b, err = os.ReadFile("./test_data/basic_otelhttp.yaml")
 	require.NoError(t, err)
@@ -92,14 +86,9 @@ func Test_ConfigureExportersEndpoints(t *testing.T) {
 	cfg, err = ConfigFromString(otelColConfig)
 	require.NoError(t, err)
 
-	err = ConfigureExporters(cfg, cm, "cluster", annotation)
+	err = ConfigureExporters(cfg, cm, addon.NewClusterValues("cluster", nil), annotation)
 	require.NoError(t, err)
-
-	exportersField = cfg["exporters"]
-	exporters = exportersField.(map[string]interface{})
-	otlphttpField := exporters["otlphttp"]
-	otlphttp := otlphttpField.(map[string]interface{})
-	require.NotNil(t, otlphttp["endpoint"])
+	require.Equal(t, "http://example.namespace.svc", cfg.Exporters["otlphttp"]["endpoint"])
 }
 
 func Test_getExporters(t *testing.T) {
@@ -113,30 +102,26 @@ func Test_getExporters(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, exporters, 1)
 
-	cfg = make(map[string]interface{})
+	cfg = &Config{}
 	_, err = getExporters(cfg)
 	require.Error(t, err)
 }
 
 func Test_configureExporterSecrets(t *testing.T) {
-	exporter := make(map[interface{}]interface{})
-	secret := corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "tracing-otlphttp-auth",
-			Namespace: "cluster-1",
-		},
-		Data: map[string][]byte{
-			"tls.crt": []byte("data"),
-			"ca.crt":  []byte("data"),
-			"tls.key": []byte("data"),
-		},
-	}
-	configureExporterSecrets(&exporter, secret)
+	exporter := Component{}
+	configureExporterSecrets(exporter, "/var/run/mcoa/tracing-otlphttp-auth", "")
 	require.NotNil(t, exporter["tls"])
+	tls := exporter["tls"].(map[string]interface{})
+	require.Equal(t, "/var/run/mcoa/tracing-otlphttp-auth/tls.crt", tls["cert_file"])
+	require.Equal(t, "/var/run/mcoa/tracing-otlphttp-auth/ca-bundle.crt", tls["ca_file"])
+
+	configureExporterSecrets(exporter, "/var/run/mcoa/tracing-otlphttp-auth", "/mcoa-trust-bundle/otlphttp.crt")
+	tls = exporter["tls"].(map[string]interface{})
+	require.Equal(t, "/mcoa-trust-bundle/otlphttp.crt", tls["ca_file"])
 }
 
 func Test_configureExporterEndpoint(t *testing.T) {
-	exporter := make(map[string]interface{})
+	exporter := Component{}
 	cm := corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "tracing-auth",
@@ -171,3 +156,38 @@ func Test_configureExporterEndpoint(t *testing.T) {
 	err = configureExporterEndpoint(exporter, cm)
 	require.Error(t, err)
 }
+
+func Test_ConfigureExportersSecrets_ExistingSettings(t *testing.T) {
+	cfg, err := ConfigFromString(`
+exporters:
+  otlphttp:
+    endpoint: https://tempo
+    tls:
+      min_version: "1.3"
+      server_name_override: tempo.example.com
+      ca_file: /etc/ca/ca.crt
+`)
+	require.NoError(t, err)
+
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "tracing-otlphttp-auth",
+			Annotations: map[string]string{
+				annotation: "otlphttp",
+			},
+		},
+	}
+
+	err = ConfigureExportersSecrets(cfg, secret, "/tracing-otlphttp-auth", "/mcoa-trust-bundle/otlphttp.crt", annotation)
+	require.NoError(t, err)
+	require.Equal(t, Component{
+		"endpoint": "https://tempo",
+		"tls": map[string]interface{}{
+			"min_version":          "1.3",
+			"server_name_override": "tempo.example.com",
+			"cert_file":            "/tracing-otlphttp-auth/tls.crt",
+			"key_file":             "/tracing-otlphttp-auth/tls.key",
+			"ca_file":              "/mcoa-trust-bundle/otlphttp.crt",
+		},
+	}, cfg.Exporters["otlphttp"])
+}
@@ -0,0 +1,60 @@
+package otelcol
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	otlpExporter     = "otlp"
+	otlpHTTPExporter = "otlphttp"
+
+	tenantHeader = "x-scope-orgid"
+)
+
+// ConfigureGatewayExporters points the otlp and otlphttp exporters that don't
+// have an endpoint to the tenant of a TempoStack gateway exposed at host. When
+// caFile is not empty the exporters verify the gateway certificate with it.
+// Returns the names of the configured exporters.
+func ConfigureGatewayExporters(cfg *Config, host, tenant string, caFile func(exporter string) string) ([]string, error) {
+	exporters, err := getExporters(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	configured := []string{}
+	for name := range exporters {
+		var endpoint string
+		switch componentType(name) {
+		case otlpHTTPExporter:
+			endpoint = fmt.Sprintf("https://%s/api/traces/v1/%s", host, tenant)
+		case otlpExporter:
+			endpoint = fmt.Sprintf("%s:443", host)
+		default:
+			continue
+		}
+
+		exporter := cfg.exporter(name)
+		if _, ok := exporter["endpoint"]; ok {
+			continue
+		}
+
+		exporter["endpoint"] = endpoint
+		exporter.Map("headers")[tenantHeader] = tenant
+		if file := caFile(name); file != "" {
+			exporter.Map("tls")["ca_file"] = file
+		}
+
+		configured = append(configured, name)
+	}
+
+	sort.Strings(configured)
+	return configured, nil
+}
+
+// componentType returns the type of a component or pipeline from its
+// <type>[/<name>] ID
+func componentType(name string) string {
+	return strings.SplitN(name, "/", 2)[0]
+}
@@ -0,0 +1,55 @@
+package otelcol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConfigureGatewayExporters(t *testing.T) {
+	cfg, err := ConfigFromString(`
+exporters:
+  otlphttp:
+    headers:
+      x-extra: value
+  otlp/tempo:
+  otlphttp/configured:
+    endpoint: https://other
+  debug:
+`)
+	require.NoError(t, err)
+
+	caFile := func(exporter string) string {
+		return "/ca/" + exporter
+	}
+
+	exporters, err := ConfigureGatewayExporters(cfg, "gateway.example.com", "cluster-1", caFile)
+	require.NoError(t, err)
+	require.Equal(t, []string{"otlp/tempo", "otlphttp"}, exporters)
+
+	require.Equal(t, map[string]Component{
+		"otlphttp": {
+			"endpoint": "https://gateway.example.com/api/traces/v1/cluster-1",
+			"headers": map[string]interface{}{
+				"x-extra":       "value",
+				"x-scope-orgid": "cluster-1",
+			},
+			"tls": map[string]interface{}{
+				"ca_file": "/ca/otlphttp",
+			},
+		},
+		"otlp/tempo": {
+			"endpoint": "gateway.example.com:443",
+			"headers": map[string]interface{}{
+				"x-scope-orgid": "cluster-1",
+			},
+			"tls": map[string]interface{}{
+				"ca_file": "/ca/otlp/tempo",
+			},
+		},
+		"otlphttp/configured": {
+			"endpoint": "https://other",
+		},
+		"debug": nil,
+	}, cfg.Exporters)
+}
@@ -0,0 +1,152 @@
+package otelcol
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Keys supported in the ConfigMaps and Secrets annotated with an exporter to
+// configure the headers sent by the exporter.
+const (
+	// tenantHeaderKey overrides the name of the header carrying the tenant
+	tenantHeaderKey = "tenantHeader"
+	// tenantKey is the template of the tenant, rendered for each cluster
+	tenantKey = "tenant"
+	// headerKeyPrefix prefixes the keys of additional headers, e.g.
+	// header.X-API-Key
+	headerKeyPrefix = "header."
+)
+
+var envVarInvalidChars = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// configureHeaders merges the tenant of the cluster and the additional
+// headers of the ConfigMap with the headers of the exporter. Header names are
+// case-insensitive, the headers of the ConfigMap replace the ones of the
+// exporter whatever their case.
+func configureHeaders(exporter Component, cm corev1.ConfigMap, values addon.ClusterValues) error {
+	header := cm.Data[tenantHeaderKey]
+	if header == "" {
+		header = tenantHeader
+	}
+
+	tenant, err := buildTenant(cm, values)
+	if err != nil {
+		return err
+	}
+
+	headers := exporter.Map("headers")
+	for key, value := range cm.Data {
+		name := strings.TrimPrefix(key, headerKeyPrefix)
+		if !strings.HasPrefix(key, headerKeyPrefix) || name == "" {
+			continue
+		}
+		if strings.EqualFold(name, header) {
+			return kverrors.New("additional header conflicts with the tenant header", "name", cm.Name, "header", header)
+		}
+		setHeader(headers, name, value)
+	}
+	setHeader(headers, header, tenant)
+	return nil
+}
+
+// setHeader sets the header, removing the headers with the same name in a
+// different case.
+func setHeader(headers map[string]interface{}, name string, value interface{}) {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			delete(headers, key)
+		}
+	}
+	headers[name] = value
+}
+
+func buildTenant(cm corev1.ConfigMap, values addon.ClusterValues) (string, error) {
+	text, ok := cm.Data[tenantKey]
+	if !ok {
+		text = addon.DefaultTenantTemplate
+	}
+
+	tenant, err := addon.RenderTenant(text, values)
+	if err != nil {
+		return "", kverrors.Wrap(err, "failed to render tenant template in configmap", "name", cm.Name)
+	}
+	return tenant, nil
+}
+
+// ConfigureSecretHeaders adds to the exporter referenced by the secret
+// annotation the headers stored in the secret. Values are not copied in the
+// configuration, they are exposed to the collector as environment variables
+// referencing the secret and expanded by the collector.
+func ConfigureSecretHeaders(spec *v1alpha1.OpenTelemetryCollectorSpec, cfg *Config, secret corev1.Secret, annotation string) error {
+	otelExporterName, ok := secret.Annotations[annotation]
+	if !ok {
+		return nil
+	}
+
+	exporters, err := getExporters(cfg)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := exporters[otelExporterName]; !ok {
+		return nil
+	}
+
+	keys := headerKeys(secret.Data)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	headers := cfg.exporter(otelExporterName).Map("headers")
+	for _, key := range keys {
+		env := headerEnvVar(secret.Name, key)
+		setHeader(headers, strings.TrimPrefix(key, headerKeyPrefix), fmt.Sprintf("${env:%s}", env))
+		configureEnvVar(spec, corev1.EnvVar{
+			Name: env,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secret.Name},
+					Key:                  key,
+				},
+			},
+		})
+	}
+	return nil
+}
+
+// configureEnvVar adds the environment variable to the collector, replacing
+// the variable of the same name if any.
+func configureEnvVar(spec *v1alpha1.OpenTelemetryCollectorSpec, env corev1.EnvVar) {
+	for i := range spec.Env {
+		if spec.Env[i].Name == env.Name {
+			spec.Env[i] = env
+			return
+		}
+	}
+	spec.Env = append(spec.Env, env)
+}
+
+// headerEnvVar returns the name of the environment variable exposing a header
+// stored in a secret, e.g. MCOA_TRACING_AUTH_X_API_KEY.
+func headerEnvVar(secret, key string) string {
+	name := fmt.Sprintf("MCOA_%s_%s", secret, strings.TrimPrefix(key, headerKeyPrefix))
+	return envVarInvalidChars.ReplaceAllString(strings.ToUpper(name), "_")
+}
+
+func headerKeys(data map[string][]byte) []string {
+	keys := []string{}
+	for key := range data {
+		if strings.HasPrefix(key, headerKeyPrefix) && len(key) > len(headerKeyPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,161 @@
+package otelcol
+
+import (
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+func Test_ConfigureHeaders(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		headers map[string]interface{}
+		data    map[string]string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "default tenant",
+			want: map[string]interface{}{"x-scope-orgid": "cluster-1"},
+		},
+		{
+			name:    "template headers are kept",
+			headers: map[string]interface{}{"x-team": "observability"},
+			want: map[string]interface{}{
+				"x-team":        "observability",
+				"x-scope-orgid": "cluster-1",
+			},
+		},
+		{
+			name: "custom tenant header and template",
+			data: map[string]string{
+				"tenantHeader": "X-Tenant",
+				"tenant":       "fleet-{{ .ClusterName }}",
+			},
+			want: map[string]interface{}{"X-Tenant": "fleet-cluster-1"},
+		},
+		{
+			name:    "tenant header of the template in a different case",
+			headers: map[string]interface{}{"X-Scope-OrgID": "fleet"},
+			want:    map[string]interface{}{"x-scope-orgid": "cluster-1"},
+		},
+		{
+			name: "tenant template with cluster labels",
+			data: map[string]string{"tenant": "{{ .Labels.env }}-{{ .ClusterName }}"},
+			want: map[string]interface{}{"x-scope-orgid": "prod-cluster-1"},
+		},
+		{
+			name:    "additional header replaces the template header in a different case",
+			headers: map[string]interface{}{"x-region": "us"},
+			data:    map[string]string{"header.X-Region": "eu"},
+			want: map[string]interface{}{
+				"X-Region":      "eu",
+				"x-scope-orgid": "cluster-1",
+			},
+		},
+		{
+			name: "additional headers",
+			data: map[string]string{
+				"header.X-Region": "eu",
+				"header.":         "ignored",
+			},
+			want: map[string]interface{}{
+				"X-Region":      "eu",
+				"x-scope-orgid": "cluster-1",
+			},
+		},
+		{
+			name:    "additional header conflicts with the tenant header",
+			data:    map[string]string{"header.x-scope-orgid": "fleet"},
+			wantErr: true,
+		},
+		{
+			name:    "additional header conflicts with the tenant header in a different case",
+			data:    map[string]string{"header.X-Scope-OrgID": "fleet"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid tenant template",
+			data:    map[string]string{"tenant": "{{ .Cluster }}"},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			exporter := Component{}
+			if tc.headers != nil {
+				exporter["headers"] = tc.headers
+			}
+			cm := corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "tracing-otlphttp"},
+				Data:       tc.data,
+			}
+
+			cluster := &clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"env": "prod"}},
+			}
+
+			err := configureHeaders(exporter, cm, addon.NewClusterValues("cluster-1", cluster))
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, exporter["headers"])
+		})
+	}
+}
+
+func Test_ConfigureSecretHeaders(t *testing.T) {
+	cfg, err := ConfigFromString(`
+exporters:
+  otlphttp:
+    endpoint: https://tempo
+    headers:
+      x-scope-orgid: cluster-1
+`)
+	require.NoError(t, err)
+
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "tracing-otlphttp-auth",
+			Annotations: map[string]string{
+				annotation: "otlphttp",
+			},
+		},
+		Data: map[string][]byte{
+			"header.X-API-Key": []byte("secret"),
+		},
+	}
+
+	spec := v1alpha1.OpenTelemetryCollectorSpec{}
+	for i := 0; i < 2; i++ {
+		err = ConfigureSecretHeaders(&spec, cfg, secret, annotation)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, map[string]interface{}{
+		"x-scope-orgid": "cluster-1",
+		"X-API-Key":     "${env:MCOA_TRACING_OTLPHTTP_AUTH_X_API_KEY}",
+	}, cfg.Exporters["otlphttp"]["headers"])
+	require.Equal(t, []corev1.EnvVar{
+		{
+			Name: "MCOA_TRACING_OTLPHTTP_AUTH_X_API_KEY",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "tracing-otlphttp-auth"},
+					Key:                  "header.X-API-Key",
+				},
+			},
+		},
+	}, spec.Env)
+
+	// Secrets holding only headers don't configure TLS
+	err = ConfigureExportersSecrets(cfg, secret, "/tracing-otlphttp-auth", "", annotation)
+	require.NoError(t, err)
+	require.Nil(t, cfg.Exporters["otlphttp"]["tls"])
+}
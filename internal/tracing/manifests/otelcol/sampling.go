@@ -0,0 +1,71 @@
+package otelcol
+
+const (
+	// ProbabilisticSamplerProcessor samples a percentage of the traces based
+	// on their trace ID
+	ProbabilisticSamplerProcessor = "probabilistic_sampler/mcoa"
+	// TailSamplingProcessor samples the traces once complete according to a
+	// list of policies
+	TailSamplingProcessor = "tail_sampling/mcoa"
+
+	memoryLimiterProcessor = "memory_limiter"
+)
+
+// ConfigureSampling adds the sampling processor to every traces pipeline,
+// after the memory_limiter processors so that they still protect the
+// collector and after the processors setting the attributes of the cluster so
+// that policies can use them. Sampling processors previously added by the
+// addon are replaced. When processor is empty sampling is removed.
+func ConfigureSampling(cfg *Config, processor string, settings Component) {
+	samplers := []string{ProbabilisticSamplerProcessor, TailSamplingProcessor}
+	for _, name := range samplers {
+		delete(cfg.Processors, name)
+	}
+
+	configured := false
+	for name, pipeline := range cfg.Service.Pipelines {
+		if componentType(name) != tracesPipeline || pipeline == nil {
+			continue
+		}
+
+		processors := []string{}
+		after := []string{k8sAttributesProcessor, clusterResourceProcessor}
+		for _, p := range pipeline.Processors {
+			if contains(samplers, p) {
+				continue
+			}
+			processors = append(processors, p)
+			if componentType(p) == memoryLimiterProcessor {
+				after = append(after, p)
+			}
+		}
+		if processor != "" {
+			processors = insertAfter(processors, processor, after...)
+			configured = true
+		}
+		pipeline.Processors = processors
+	}
+	if !configured {
+		return
+	}
+
+	if cfg.Processors == nil {
+		cfg.Processors = map[string]Component{}
+	}
+	cfg.Processors[processor] = settings
+}
+
+// insertAfter inserts the processor after the last of the given processors
+// found in the list, or at its beginning when none is found.
+func insertAfter(list []string, processor string, after ...string) []string {
+	index := 0
+	for i, name := range list {
+		if contains(after, name) {
+			index = i + 1
+		}
+	}
+
+	result := append([]string{}, list[:index]...)
+	result = append(result, processor)
+	return append(result, list[index:]...)
+}
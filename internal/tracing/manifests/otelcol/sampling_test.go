@@ -0,0 +1,69 @@
+package otelcol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConfigureSampling(t *testing.T) {
+	cfg, err := ConfigFromString(`
+receivers:
+  otlp:
+processors:
+  batch:
+exporters:
+  otlphttp:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [otlphttp]
+    metrics:
+      receivers: [otlp]
+      exporters: [otlphttp]
+`)
+	require.NoError(t, err)
+
+	ConfigureSampling(cfg, ProbabilisticSamplerProcessor, Component{"sampling_percentage": 10.0})
+	require.Equal(t, []string{ProbabilisticSamplerProcessor, "batch"}, cfg.Service.Pipelines["traces"].Processors)
+	require.Empty(t, cfg.Service.Pipelines["metrics"].Processors)
+
+	// Sampling runs after the cluster attributes are set
+	ConfigureK8sAttributes(cfg)
+	ConfigureClusterAttributes(cfg, map[string]string{ClusterNameAttribute: "cluster-1"})
+	ConfigureSampling(cfg, TailSamplingProcessor, Component{"policies": []interface{}{}})
+	require.Equal(t, []string{"k8sattributes/mcoa", "resource/mcoa-cluster", TailSamplingProcessor, "batch"}, cfg.Service.Pipelines["traces"].Processors)
+	require.NotContains(t, cfg.Processors, ProbabilisticSamplerProcessor)
+	require.Contains(t, cfg.Processors, TailSamplingProcessor)
+	require.Empty(t, Validate(cfg))
+
+	ConfigureSampling(cfg, "", nil)
+	require.Equal(t, []string{"k8sattributes/mcoa", "resource/mcoa-cluster", "batch"}, cfg.Service.Pipelines["traces"].Processors)
+	require.NotContains(t, cfg.Processors, TailSamplingProcessor)
+}
+
+func Test_ConfigureSampling_MemoryLimiter(t *testing.T) {
+	cfg, err := ConfigFromString(`
+receivers:
+  otlp:
+processors:
+  memory_limiter/spans:
+    check_interval: 1s
+    limit_percentage: 75
+  batch:
+exporters:
+  otlphttp:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [memory_limiter/spans, batch]
+      exporters: [otlphttp]
+`)
+	require.NoError(t, err)
+
+	ConfigureSampling(cfg, ProbabilisticSamplerProcessor, Component{"sampling_percentage": 10.0})
+	require.Equal(t, []string{"memory_limiter/spans", ProbabilisticSamplerProcessor, "batch"}, cfg.Service.Pipelines["traces"].Processors)
+}
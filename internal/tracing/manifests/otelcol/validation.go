@@ -0,0 +1,124 @@
+package otelcol
+
+import (
+	_ "embed"
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed components.yaml
+var componentsYAML []byte
+
+// distributionComponents lists by kind the component types shipped by the
+// collector distribution deployed on the spokes
+var distributionComponents = func() map[string]map[string]struct{} {
+	kinds := map[string][]string{}
+	if err := yaml.Unmarshal(componentsYAML, &kinds); err != nil {
+		panic(fmt.Sprintf("invalid embedded collector components: %v", err))
+	}
+
+	components := make(map[string]map[string]struct{}, len(kinds))
+	for kind, types := range kinds {
+		components[kind] = make(map[string]struct{}, len(types))
+		for _, t := range types {
+			components[kind][t] = struct{}{}
+		}
+	}
+	return components
+}()
+
+var pipelineTypes = map[string]struct{}{
+	"traces":  {},
+	"metrics": {},
+	"logs":    {},
+}
+
+// Validate checks that the components of the configuration are shipped by
+// the collector distribution and that the pipelines and the service only
+// reference defined components. Returns a finding for each problem.
+func Validate(cfg *Config) []string {
+	var findings []string
+
+	kinds := []struct {
+		name       string
+		components map[string]Component
+	}{
+		{"receivers", cfg.Receivers},
+		{"processors", cfg.Processors},
+		{"exporters", cfg.Exporters},
+		{"extensions", cfg.Extensions},
+		{"connectors", cfg.Connectors},
+	}
+	for _, kind := range kinds {
+		for _, id := range sortedKeys(kind.components) {
+			if _, ok := distributionComponents[kind.name][componentType(id)]; !ok {
+				findings = append(findings, fmt.Sprintf("%s %q has a type unknown to the collector distribution", singular(kind.name), id))
+			}
+		}
+	}
+
+	for _, id := range cfg.Service.Extensions {
+		if _, ok := cfg.Extensions[id]; !ok {
+			findings = append(findings, fmt.Sprintf("service references unknown extension %q", id))
+		}
+	}
+
+	pipelines := make([]string, 0, len(cfg.Service.Pipelines))
+	for id := range cfg.Service.Pipelines {
+		pipelines = append(pipelines, id)
+	}
+	sort.Strings(pipelines)
+
+	for _, id := range pipelines {
+		pipeline := cfg.Service.Pipelines[id]
+		if _, ok := pipelineTypes[componentType(id)]; !ok {
+			findings = append(findings, fmt.Sprintf("pipeline %q has an unknown signal type", id))
+		}
+		if pipeline == nil || len(pipeline.Receivers) == 0 || len(pipeline.Exporters) == 0 {
+			findings = append(findings, fmt.Sprintf("pipeline %q requires at least a receiver and an exporter", id))
+			continue
+		}
+
+		for _, ref := range pipeline.Receivers {
+			if !defined(ref, cfg.Receivers, cfg.Connectors) {
+				findings = append(findings, fmt.Sprintf("pipeline %q references unknown receiver %q", id, ref))
+			}
+		}
+		for _, ref := range pipeline.Processors {
+			if !defined(ref, cfg.Processors) {
+				findings = append(findings, fmt.Sprintf("pipeline %q references unknown processor %q", id, ref))
+			}
+		}
+		for _, ref := range pipeline.Exporters {
+			if !defined(ref, cfg.Exporters, cfg.Connectors) {
+				findings = append(findings, fmt.Sprintf("pipeline %q references unknown exporter %q", id, ref))
+			}
+		}
+	}
+
+	return findings
+}
+
+func defined(id string, kinds ...map[string]Component) bool {
+	for _, components := range kinds {
+		if _, ok := components[id]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func singular(kind string) string {
+	return kind[:len(kind)-1]
+}
+
+func sortedKeys(components map[string]Component) []string {
+	keys := make([]string, 0, len(components))
+	for k := range components {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,61 @@
+package otelcol
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Validate(t *testing.T) {
+	cfg, err := ConfigFromString(`
+receivers:
+  otlp:
+  unknownreceiver:
+processors:
+  batch:
+exporters:
+  otlphttp/tempo:
+connectors:
+  spanmetrics:
+extensions:
+  health_check:
+service:
+  extensions: [health_check, oidc]
+  pipelines:
+    traces:
+      receivers: [otlp, jaeger]
+      processors: [batch, memory_limiter]
+      exporters: [otlphttp/tempo, spanmetrics, otlphttp]
+    metrics/spans:
+      receivers: [spanmetrics]
+      exporters: [otlphttp/tempo]
+    profiles:
+      receivers: [otlp]
+    logs:
+      receivers: [otlp]
+      exporters: [otlphttp/tempo]
+`)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		`receiver "unknownreceiver" has a type unknown to the collector distribution`,
+		`service references unknown extension "oidc"`,
+		`pipeline "profiles" has an unknown signal type`,
+		`pipeline "profiles" requires at least a receiver and an exporter`,
+		`pipeline "traces" references unknown receiver "jaeger"`,
+		`pipeline "traces" references unknown processor "memory_limiter"`,
+		`pipeline "traces" references unknown exporter "otlphttp"`,
+	}, Validate(cfg))
+}
+
+func Test_Validate_TestData(t *testing.T) {
+	for _, file := range []string{"simplest.yaml", "basic_otelhttp.yaml"} {
+		b, err := os.ReadFile("./test_data/" + file)
+		require.NoError(t, err)
+
+		cfg, err := ConfigFromString(string(b))
+		require.NoError(t, err)
+		require.Empty(t, Validate(cfg), file)
+	}
+}
@@ -1,19 +1,92 @@
 package otelcol
 
 import (
+	"fmt"
+
 	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 )
 
-func ConfigureVolumes(spec *v1alpha1.OpenTelemetryCollectorSpec, secret corev1.Secret) {
-	v := corev1.Volume{
-		Name: secret.Name,
+// VolumeConflictError reports a volume or a volume mount of the template that
+// prevents the addon from injecting the volume of an exporter.
+type VolumeConflictError struct {
+	// Volume is the name of the volume injected by the addon
+	Volume string
+	// Path is the mount path of the injected volume, empty for volume conflicts
+	Path string
+	// Conflict is the name of the template volume it conflicts with
+	Conflict string
+}
+
+func (e *VolumeConflictError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("volume %q is already defined with another source", e.Volume)
+	}
+	return fmt.Sprintf("mount path %q of volume %q overlaps with the mount of volume %q", e.Path, e.Volume, e.Conflict)
+}
+
+// ConfigureVolumes adds a volume for the secret and returns its name. When the
+// template already has a volume for the secret it is reused instead.
+func ConfigureVolumes(spec *v1alpha1.OpenTelemetryCollectorSpec, secret corev1.Secret) (string, error) {
+	return configureVolume(spec, secretVolume(secret.Name))
+}
+
+// ConfigureTrustBundleVolume adds a volume for the ConfigMap holding the CA
+// bundles of the exporters and returns its name. When the template already
+// has a volume for the ConfigMap it is reused instead.
+func ConfigureTrustBundleVolume(spec *v1alpha1.OpenTelemetryCollectorSpec, name string) (string, error) {
+	return configureVolume(spec, configMapVolume(name))
+}
+
+func configureVolume(spec *v1alpha1.OpenTelemetryCollectorSpec, v corev1.Volume) (string, error) {
+	if name, ok := findVolume(spec, v); ok {
+		return name, nil
+	}
+
+	for _, existing := range spec.Volumes {
+		if existing.Name == v.Name {
+			return "", &VolumeConflictError{Volume: v.Name, Conflict: existing.Name}
+		}
+	}
+
+	spec.Volumes = append(spec.Volumes, v)
+	return v.Name, nil
+}
+
+// findVolume returns the name of the volume of the spec with the same secret
+// or ConfigMap source as v.
+func findVolume(spec *v1alpha1.OpenTelemetryCollectorSpec, v corev1.Volume) (string, bool) {
+	for _, existing := range spec.Volumes {
+		switch {
+		case v.Secret != nil && existing.Secret != nil && existing.Secret.SecretName == v.Secret.SecretName:
+			return existing.Name, true
+		case v.ConfigMap != nil && existing.ConfigMap != nil && existing.ConfigMap.Name == v.ConfigMap.Name:
+			return existing.Name, true
+		}
+	}
+	return "", false
+}
+
+func secretVolume(name string) corev1.Volume {
+	return corev1.Volume{
+		Name: name,
 		VolumeSource: corev1.VolumeSource{
 			Secret: &corev1.SecretVolumeSource{
-				SecretName: secret.Name,
+				SecretName: name,
 			},
 		},
 	}
+}
 
-	spec.Volumes = append(spec.Volumes, v)
+func configMapVolume(name string) corev1.Volume {
+	return corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: name,
+				},
+			},
+		},
+	}
 }
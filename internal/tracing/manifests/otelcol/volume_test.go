@@ -25,8 +25,82 @@ func Test_ConfigureVolumes(t *testing.T) {
 		},
 	}
 
+	for _, tc := range []struct {
+		name    string
+		volumes []corev1.Volume
+		want    string
+		wantLen int
+		wantErr bool
+	}{
+		{
+			name:    "no volumes",
+			want:    "tracing-otlphttp-auth",
+			wantLen: 1,
+		},
+		{
+			name:    "secret already mounted",
+			volumes: []corev1.Volume{secretVolume("tracing-otlphttp-auth")},
+			want:    "tracing-otlphttp-auth",
+			wantLen: 1,
+		},
+		{
+			name: "secret mounted with another volume name",
+			volumes: []corev1.Volume{
+				{
+					Name: "otlphttp-certs",
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{SecretName: "tracing-otlphttp-auth"},
+					},
+				},
+			},
+			want:    "otlphttp-certs",
+			wantLen: 1,
+		},
+		{
+			name: "volume name used by another source",
+			volumes: []corev1.Volume{
+				{
+					Name: "tracing-otlphttp-auth",
+					VolumeSource: corev1.VolumeSource{
+						EmptyDir: &corev1.EmptyDirVolumeSource{},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			otelSpec := v1alpha1.OpenTelemetryCollectorSpec{Volumes: tc.volumes}
+
+			name, err := ConfigureVolumes(&otelSpec, secret)
+			if tc.wantErr {
+				require.Error(t, err)
+				require.IsType(t, &VolumeConflictError{}, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, name)
+			require.Len(t, otelSpec.Volumes, tc.wantLen)
+
+			// Configuring the volume again doesn't change the spec
+			name, err = ConfigureVolumes(&otelSpec, secret)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, name)
+			require.Len(t, otelSpec.Volumes, tc.wantLen)
+		})
+	}
+}
+
+func Test_ConfigureTrustBundleVolume(t *testing.T) {
 	otelSpec := v1alpha1.OpenTelemetryCollectorSpec{}
 
-	ConfigureVolumes(&otelSpec, secret)
-	require.NotEmpty(t, otelSpec.Volumes)
+	name, err := ConfigureTrustBundleVolume(&otelSpec, "mcoa-trust-bundle")
+	require.NoError(t, err)
+	require.Equal(t, "mcoa-trust-bundle", name)
+	require.Len(t, otelSpec.Volumes, 1)
+	require.Equal(t, "mcoa-trust-bundle", otelSpec.Volumes[0].ConfigMap.Name)
+
+	_, err = ConfigureTrustBundleVolume(&otelSpec, "mcoa-trust-bundle")
+	require.NoError(t, err)
+	require.Len(t, otelSpec.Volumes, 1)
 }
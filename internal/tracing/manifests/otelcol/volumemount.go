@@ -1,17 +1,79 @@
 package otelcol
 
 import (
-	"fmt"
+	"path"
+	"strings"
 
 	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 )
 
-func ConfigureVolumeMounts(spec *v1alpha1.OpenTelemetryCollectorSpec, secret corev1.Secret) {
-	vm := corev1.VolumeMount{
-		Name:      secret.Name,
-		MountPath: fmt.Sprintf("/%s", secret.Name),
+// ConfigureVolumeMounts mounts the volume under root and returns its mount
+// path. When the template already mounts the volume its mount path is reused.
+func ConfigureVolumeMounts(spec *v1alpha1.OpenTelemetryCollectorSpec, volume, root string) (string, error) {
+	return configureVolumeMount(spec, volume, root, false)
+}
+
+// ConfigureTrustBundleVolumeMount mounts the volume of the ConfigMap holding
+// the CA bundles of the exporters, it follows the same rules as
+// ConfigureVolumeMounts.
+func ConfigureTrustBundleVolumeMount(spec *v1alpha1.OpenTelemetryCollectorSpec, volume, root string) (string, error) {
+	return configureVolumeMount(spec, volume, root, true)
+}
+
+// MountPath returns the path where the volume is mounted by the template or,
+// when it isn't, the path where the addon mounts it under root.
+func MountPath(spec *v1alpha1.OpenTelemetryCollectorSpec, volume, root string) string {
+	if vm, ok := findVolumeMount(spec, volume); ok {
+		return vm.MountPath
 	}
+	return path.Join("/", root, volume)
+}
+
+// TrustBundleMountPath returns the path where the ConfigMap holding the CA
+// bundles of the exporters is mounted once the template is configured.
+func TrustBundleMountPath(spec *v1alpha1.OpenTelemetryCollectorSpec, name, root string) string {
+	volume := name
+	if existing, ok := findVolume(spec, configMapVolume(name)); ok {
+		volume = existing
+	}
+	return MountPath(spec, volume, root)
+}
+
+func configureVolumeMount(spec *v1alpha1.OpenTelemetryCollectorSpec, volume, root string, readOnly bool) (string, error) {
+	if vm, ok := findVolumeMount(spec, volume); ok {
+		return vm.MountPath, nil
+	}
+
+	mountPath := path.Join("/", root, volume)
+	for _, existing := range spec.VolumeMounts {
+		if overlaps(existing.MountPath, mountPath) {
+			return "", &VolumeConflictError{Volume: volume, Path: mountPath, Conflict: existing.Name}
+		}
+	}
+
+	spec.VolumeMounts = append(spec.VolumeMounts, corev1.VolumeMount{
+		Name:      volume,
+		MountPath: mountPath,
+		ReadOnly:  readOnly,
+	})
+	return mountPath, nil
+}
+
+// findVolumeMount returns the mount of the whole volume, mounts of a subpath
+// of the volume don't expose all its keys and are ignored.
+func findVolumeMount(spec *v1alpha1.OpenTelemetryCollectorSpec, volume string) (corev1.VolumeMount, bool) {
+	for _, vm := range spec.VolumeMounts {
+		if vm.Name == volume && vm.SubPath == "" && vm.SubPathExpr == "" {
+			return vm, true
+		}
+	}
+	return corev1.VolumeMount{}, false
+}
 
-	spec.VolumeMounts = append(spec.VolumeMounts, vm)
+// overlaps returns true when the paths are the same or one is nested in the
+// other, in both cases one of the mounts would shadow files of the other.
+func overlaps(a, b string) bool {
+	a, b = path.Clean(a), path.Clean(b)
+	return a == b || strings.HasPrefix(a, strings.TrimSuffix(b, "/")+"/") || strings.HasPrefix(b, strings.TrimSuffix(a, "/")+"/")
 }
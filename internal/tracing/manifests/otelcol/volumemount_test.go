@@ -6,27 +6,113 @@ import (
 	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func Test_ConfigureVolumeMounts(t *testing.T) {
-	secret := corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "tracing-otlphttp-auth",
-			Namespace: "cluster-1",
-			Annotations: map[string]string{
-				annotation: "otlphttp",
+	for _, tc := range []struct {
+		name    string
+		root    string
+		mounts  []corev1.VolumeMount
+		want    string
+		wantLen int
+		wantErr bool
+	}{
+		{
+			name:    "default root",
+			root:    "/",
+			want:    "/tracing-otlphttp-auth",
+			wantLen: 1,
+		},
+		{
+			name:    "custom root",
+			root:    "/var/run/mcoa",
+			want:    "/var/run/mcoa/tracing-otlphttp-auth",
+			wantLen: 1,
+		},
+		{
+			name: "volume already mounted",
+			root: "/",
+			mounts: []corev1.VolumeMount{
+				{Name: "tracing-otlphttp-auth", MountPath: "/etc/otlphttp"},
+			},
+			want:    "/etc/otlphttp",
+			wantLen: 1,
+		},
+		{
+			name: "subpath of the volume mounted",
+			root: "/",
+			mounts: []corev1.VolumeMount{
+				{Name: "tracing-otlphttp-auth", MountPath: "/etc/otlphttp/tls.crt", SubPath: "tls.crt"},
+			},
+			want:    "/tracing-otlphttp-auth",
+			wantLen: 2,
+		},
+		{
+			name: "path used by another volume",
+			root: "/",
+			mounts: []corev1.VolumeMount{
+				{Name: "certs", MountPath: "/tracing-otlphttp-auth"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "path nested in another volume mount",
+			root: "/etc/otel",
+			mounts: []corev1.VolumeMount{
+				{Name: "config", MountPath: "/etc/otel"},
 			},
+			wantErr: true,
 		},
-		Data: map[string][]byte{
-			"tls.crt": []byte("data"),
-			"ca.crt":  []byte("data"),
-			"tls.key": []byte("data"),
+		{
+			name: "path with a common prefix",
+			root: "/etc/otel",
+			mounts: []corev1.VolumeMount{
+				{Name: "config", MountPath: "/etc/otel/tracing-otlphttp"},
+			},
+			want:    "/etc/otel/tracing-otlphttp-auth",
+			wantLen: 2,
 		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			otelSpec := v1alpha1.OpenTelemetryCollectorSpec{VolumeMounts: tc.mounts}
+
+			mountPath, err := ConfigureVolumeMounts(&otelSpec, "tracing-otlphttp-auth", tc.root)
+			if tc.wantErr {
+				require.Error(t, err)
+				require.IsType(t, &VolumeConflictError{}, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, mountPath)
+			require.Equal(t, tc.want, MountPath(&otelSpec, "tracing-otlphttp-auth", tc.root))
+			require.Len(t, otelSpec.VolumeMounts, tc.wantLen)
+
+			// Mounting the volume again doesn't change the spec
+			mountPath, err = ConfigureVolumeMounts(&otelSpec, "tracing-otlphttp-auth", tc.root)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, mountPath)
+			require.Len(t, otelSpec.VolumeMounts, tc.wantLen)
+		})
 	}
+}
 
+func Test_ConfigureTrustBundleVolumeMount(t *testing.T) {
 	otelSpec := v1alpha1.OpenTelemetryCollectorSpec{}
 
-	ConfigureVolumeMounts(&otelSpec, secret)
-	require.NotEmpty(t, otelSpec.VolumeMounts)
+	mountPath, err := ConfigureTrustBundleVolumeMount(&otelSpec, "mcoa-trust-bundle", "/")
+	require.NoError(t, err)
+	require.Equal(t, "/mcoa-trust-bundle", mountPath)
+	require.Len(t, otelSpec.VolumeMounts, 1)
+	require.True(t, otelSpec.VolumeMounts[0].ReadOnly)
+}
+
+func Test_TrustBundleMountPath(t *testing.T) {
+	otelSpec := v1alpha1.OpenTelemetryCollectorSpec{
+		Volumes: []corev1.Volume{configMapVolume("mcoa-trust-bundle")},
+	}
+	otelSpec.Volumes[0].Name = "ca"
+	otelSpec.VolumeMounts = []corev1.VolumeMount{{Name: "ca", MountPath: "/etc/ca"}}
+
+	require.Equal(t, "/etc/ca", TrustBundleMountPath(&otelSpec, "mcoa-trust-bundle", "/"))
+	require.Equal(t, "/tmp/mcoa-trust-bundle", TrustBundleMountPath(&v1alpha1.OpenTelemetryCollectorSpec{}, "mcoa-trust-bundle", "/tmp"))
 }
@@ -0,0 +1,109 @@
+package manifests
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rhobs/multicluster-observability-addon/internal/tracing/manifests/otelcol"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Keys supported in the ConfigMaps annotated with AnnotationSampling
+const (
+	samplingModeKey         = "mode"
+	samplingPercentageKey   = "percentage"
+	samplingPoliciesKey     = "policies"
+	samplingDecisionWaitKey = "decisionWait"
+)
+
+// SamplingMode selects the sampling processor added to the traces pipelines
+type SamplingMode string
+
+const (
+	// SamplingModeNone disables the sampling configured by broader ConfigMaps
+	SamplingModeNone SamplingMode = "none"
+	// SamplingModeProbabilistic keeps a percentage of the traces
+	SamplingModeProbabilistic SamplingMode = "probabilistic"
+	// SamplingModeTail keeps the traces matching a list of tail_sampling
+	// policies
+	SamplingModeTail SamplingMode = "tail"
+)
+
+// buildSamplingPolicy merges the keys of the sampling ConfigMaps, ordered from
+// the broadest to the most specific, so that a key set by a more specific
+// ConfigMap overrides the same key of the broader ones.
+func buildSamplingPolicy(cms []corev1.ConfigMap) map[string]string {
+	policy := map[string]string{}
+	for _, cm := range cms {
+		for _, key := range []string{samplingModeKey, samplingPercentageKey, samplingPoliciesKey, samplingDecisionWaitKey} {
+			if value, ok := cm.Data[key]; ok {
+				policy[key] = value
+			}
+		}
+	}
+	return policy
+}
+
+// buildSamplingProcessor returns the sampling processor and its settings for
+// the policy, an empty processor name disables sampling. Invalid policies are
+// reported as findings.
+func buildSamplingProcessor(policy map[string]string) (string, otelcol.Component, []string) {
+	switch mode := SamplingMode(policy[samplingModeKey]); mode {
+	case "", SamplingModeNone:
+		return "", nil, nil
+	case SamplingModeProbabilistic:
+		percentage, err := strconv.ParseFloat(policy[samplingPercentageKey], 64)
+		if err != nil || percentage < 0 || percentage > 100 {
+			return "", nil, []string{fmt.Sprintf("sampling percentage %q must be a number between 0 and 100", policy[samplingPercentageKey])}
+		}
+		return otelcol.ProbabilisticSamplerProcessor, otelcol.Component{
+			"sampling_percentage": percentage,
+		}, nil
+	case SamplingModeTail:
+		var policies []interface{}
+		if err := yaml.Unmarshal([]byte(policy[samplingPoliciesKey]), &policies); err != nil || len(policies) == 0 {
+			return "", nil, []string{"tail sampling requires a list of policies"}
+		}
+		settings := otelcol.Component{
+			"policies": policies,
+		}
+		if wait, ok := policy[samplingDecisionWaitKey]; ok {
+			if _, err := time.ParseDuration(wait); err != nil {
+				return "", nil, []string{fmt.Sprintf("sampling decision wait %q is not a duration", wait)}
+			}
+			settings["decision_wait"] = wait
+		}
+		return otelcol.TailSamplingProcessor, settings, nil
+	default:
+		return "", nil, []string{fmt.Sprintf("unknown sampling mode %q", mode)}
+	}
+}
+
+// useTailSampling returns true when the traces of the cluster are sampled by
+// the tail sampling processor
+func useTailSampling(resources Options) bool {
+	processor, _, _ := buildSamplingProcessor(buildSamplingPolicy(resources.Sampling))
+	return processor == otelcol.TailSamplingProcessor
+}
+
+// configureSampling adds the sampling processor of the cluster to every
+// traces pipeline of the collector.
+func configureSampling(resources Options) error {
+	processor, settings, findings := buildSamplingProcessor(buildSamplingPolicy(resources.Sampling))
+	if len(findings) > 0 {
+		return newValidationError(findings)
+	}
+
+	spec := &resources.OpenTelemetryCollector.Spec
+	cfg, err := otelcol.ConfigFromString(spec.Config)
+	if err != nil {
+		return err
+	}
+
+	otelcol.ConfigureSampling(cfg, processor, settings)
+
+	spec.Config, err = otelcol.ConfigToString(cfg)
+	return err
+}
@@ -0,0 +1,173 @@
+package manifests
+
+import (
+	"testing"
+
+	otelv1alpha1 "github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/rhobs/multicluster-observability-addon/internal/tracing/manifests/otelcol"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_BuildSamplingProcessor(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		cms           []map[string]string
+		wantProcessor string
+		wantSettings  otelcol.Component
+		wantFindings  []string
+	}{
+		{
+			name: "no sampling",
+		},
+		{
+			name: "probabilistic",
+			cms: []map[string]string{
+				{"mode": "probabilistic", "percentage": "25"},
+			},
+			wantProcessor: otelcol.ProbabilisticSamplerProcessor,
+			wantSettings:  otelcol.Component{"sampling_percentage": 25.0},
+		},
+		{
+			name: "cluster override of the percentage",
+			cms: []map[string]string{
+				{"mode": "probabilistic", "percentage": "25"},
+				{"percentage": "1.5"},
+			},
+			wantProcessor: otelcol.ProbabilisticSamplerProcessor,
+			wantSettings:  otelcol.Component{"sampling_percentage": 1.5},
+		},
+		{
+			name: "cluster disables sampling",
+			cms: []map[string]string{
+				{"mode": "probabilistic", "percentage": "25"},
+				{"mode": "none"},
+			},
+		},
+		{
+			name: "tail",
+			cms: []map[string]string{
+				{
+					"mode":         "tail",
+					"decisionWait": "30s",
+					"policies": `
+- name: errors
+  type: status_code
+  status_code:
+    status_codes: [ERROR]
+`,
+				},
+			},
+			wantProcessor: otelcol.TailSamplingProcessor,
+			wantSettings: otelcol.Component{
+				"decision_wait": "30s",
+				"policies": []interface{}{
+					map[string]interface{}{
+						"name": "errors",
+						"type": "status_code",
+						"status_code": map[string]interface{}{
+							"status_codes": []interface{}{"ERROR"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "invalid percentage",
+			cms: []map[string]string{
+				{"mode": "probabilistic", "percentage": "150"},
+			},
+			wantFindings: []string{`sampling percentage "150" must be a number between 0 and 100`},
+		},
+		{
+			name: "tail without policies",
+			cms: []map[string]string{
+				{"mode": "tail"},
+			},
+			wantFindings: []string{"tail sampling requires a list of policies"},
+		},
+		{
+			name: "unknown mode",
+			cms: []map[string]string{
+				{"mode": "head"},
+			},
+			wantFindings: []string{`unknown sampling mode "head"`},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var cms []corev1.ConfigMap
+			for _, data := range tc.cms {
+				cms = append(cms, corev1.ConfigMap{Data: data})
+			}
+
+			processor, settings, findings := buildSamplingProcessor(buildSamplingPolicy(cms))
+			require.Equal(t, tc.wantFindings, findings)
+			require.Equal(t, tc.wantProcessor, processor)
+			require.Equal(t, tc.wantSettings, settings)
+		})
+	}
+}
+
+func Test_ConfigureSampling(t *testing.T) {
+	resources := Options{
+		OpenTelemetryCollector: &otelv1alpha1.OpenTelemetryCollector{
+			Spec: otelv1alpha1.OpenTelemetryCollectorSpec{
+				Config: validationConfig,
+			},
+		},
+		Sampling: []corev1.ConfigMap{
+			{Data: map[string]string{"mode": "probabilistic", "percentage": "10"}},
+		},
+	}
+
+	require.NoError(t, configureSampling(resources))
+
+	cfg, err := otelcol.ConfigFromString(resources.OpenTelemetryCollector.Spec.Config)
+	require.NoError(t, err)
+	require.Equal(t, []string{otelcol.ProbabilisticSamplerProcessor}, cfg.Service.Pipelines["traces"].Processors)
+}
+
+func Test_TemplateAgentOtelColSpec_TailSampling(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		mode     string
+		exporter string
+	}{
+		{
+			name:     "probabilistic sampling",
+			mode:     "probabilistic",
+			exporter: "otlp/mcoa-gateway",
+		},
+		{
+			name:     "tail sampling",
+			mode:     "tail",
+			exporter: "loadbalancing/mcoa-gateway",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resources := Options{
+				OpenTelemetryCollector: &otelv1alpha1.OpenTelemetryCollector{
+					Spec: otelv1alpha1.OpenTelemetryCollectorSpec{
+						Config: `
+receivers:
+  otlp:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+`,
+					},
+				},
+				Sampling: []corev1.ConfigMap{
+					{Data: map[string]string{"mode": tc.mode, "percentage": "10", "policies": "[{name: errors, type: status_code}]"}},
+				},
+			}
+
+			require.NoError(t, templateAgentOtelColSpec(resources))
+
+			cfg, err := otelcol.ConfigFromString(resources.OpenTelemetryCollector.Spec.Config)
+			require.NoError(t, err)
+			require.Equal(t, []string{tc.exporter}, cfg.Service.Pipelines["traces"].Exporters)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package manifests
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
+	"github.com/rhobs/multicluster-observability-addon/internal/tracing/manifests/otelcol"
+)
+
+// TempoStackGateway describes the TempoStack gateway discovered on the hub
+// that receives the traces of the spoke clusters.
+type TempoStackGateway struct {
+	// Host of the Route exposing the gateway
+	Host string
+	// CA is the PEM encoded CA bundle that signed the gateway certificate
+	CA string
+	// Tenant configured in the AddOnDeploymentConfig for all clusters, it
+	// takes precedence over the discovered tenants
+	Tenant string
+	// Tenants discovered in the TempoStack owning the gateway Route
+	Tenants []string
+}
+
+// configureTempoStackGateway points the OTLP exporters of the template that
+// don't have an endpoint to the TempoStack gateway and adds the gateway CA to
+// their bundles. Exporters configured with a ConfigMap keep the values of the
+// ConfigMap.
+func configureTempoStackGateway(resources *Options) error {
+	gateway := resources.TempoStackGateway
+	if gateway == nil {
+		return nil
+	}
+
+	tenant, err := tempoStackTenant(gateway, resources.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	spec := &resources.OpenTelemetryCollector.Spec
+
+	root, err := volumeMountRoot(*resources)
+	if err != nil {
+		return err
+	}
+	trustBundleDir := otelcol.TrustBundleMountPath(spec, authentication.TrustBundleName, root)
+
+	caFile := func(exporter string) string {
+		if gateway.CA == "" {
+			return ""
+		}
+		return path.Join(trustBundleDir, authentication.TrustBundleKey(authentication.Target(exporter)))
+	}
+
+	cfg, err := otelcol.ConfigFromString(spec.Config)
+	if err != nil {
+		return err
+	}
+
+	exporters, err := otelcol.ConfigureGatewayExporters(cfg, gateway.Host, tenant, caFile)
+	if err != nil {
+		return err
+	}
+	if len(exporters) == 0 {
+		return nil
+	}
+
+	spec.Config, err = otelcol.ConfigToString(cfg)
+	if err != nil {
+		return err
+	}
+
+	if gateway.CA == "" {
+		return nil
+	}
+
+	bundles := authentication.CABundles{}
+	for target, ca := range resources.CABundles {
+		bundles[target] = ca
+	}
+	for _, exporter := range exporters {
+		bundles.Add(gateway.CA, authentication.Target(exporter))
+	}
+	resources.CABundles = bundles
+
+	return nil
+}
+
+// tempoStackTenant returns the tenant of the TempoStack receiving the traces of
+// the cluster. The tenant configured in the AddOnDeploymentConfig must be one
+// of the discovered tenants, otherwise the tenant named after the cluster or
+// the only tenant of the TempoStack is used. Without discovered tenants the
+// cluster name is used.
+func tempoStackTenant(gateway *TempoStackGateway, clusterName string) (string, error) {
+	hasTenant := func(name string) bool {
+		for _, tenant := range gateway.Tenants {
+			if tenant == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case gateway.Tenant != "":
+		if len(gateway.Tenants) > 0 && !hasTenant(gateway.Tenant) {
+			return "", newValidationError([]string{
+				fmt.Sprintf("TempoStack has no tenant %q, expected one of %s", gateway.Tenant, strings.Join(gateway.Tenants, ", ")),
+			})
+		}
+		return gateway.Tenant, nil
+	case len(gateway.Tenants) == 0, hasTenant(clusterName):
+		return clusterName, nil
+	case len(gateway.Tenants) == 1:
+		return gateway.Tenants[0], nil
+	}
+
+	return "", newValidationError([]string{
+		fmt.Sprintf("TempoStack has no tenant %q, set the tracingTempoStackTenant variable to one of %s", clusterName, strings.Join(gateway.Tenants, ", ")),
+	})
+}
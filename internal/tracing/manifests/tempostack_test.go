@@ -0,0 +1,59 @@
+package manifests
+
+import (
+	"testing"
+
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TempoStackTenant(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		gateway TempoStackGateway
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "no tenants discovered",
+			gateway: TempoStackGateway{},
+			want:    "cluster-1",
+		},
+		{
+			name:    "tenant named after the cluster",
+			gateway: TempoStackGateway{Tenants: []string{"dev", "cluster-1"}},
+			want:    "cluster-1",
+		},
+		{
+			name:    "single tenant",
+			gateway: TempoStackGateway{Tenants: []string{"fleet"}},
+			want:    "fleet",
+		},
+		{
+			name:    "configured tenant",
+			gateway: TempoStackGateway{Tenant: "prod", Tenants: []string{"dev", "prod"}},
+			want:    "prod",
+		},
+		{
+			name:    "configured tenant not discovered",
+			gateway: TempoStackGateway{Tenant: "qa", Tenants: []string{"dev", "prod"}},
+			wantErr: true,
+		},
+		{
+			name:    "several tenants",
+			gateway: TempoStackGateway{Tenants: []string{"dev", "prod"}},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tenant, err := tempoStackTenant(&tc.gateway, "cluster-1")
+			if tc.wantErr {
+				var verr *addon.ValidationError
+				require.ErrorAs(t, err, &verr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, tenant)
+		})
+	}
+}
@@ -2,12 +2,16 @@ package manifests
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"path"
 
 	"github.com/ViaQ/logerr/v2/kverrors"
 	otelv1alpha1 "github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/patch"
 	"github.com/rhobs/multicluster-observability-addon/internal/tracing/manifests/otelcol"
-	"gopkg.in/yaml.v3"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -27,45 +31,215 @@ func buildSecrets(resources Options) ([]SecretValue, error) {
 	return secretsValue, nil
 }
 
-func buildOtelColSpec(resources Options) (*otelv1alpha1.OpenTelemetryCollectorSpec, error) {
+// buildOtelColSpec templates the collector with configure and applies the
+// patches targeting it.
+func buildOtelColSpec(resources Options, configure func(Options) error) (*otelv1alpha1.OpenTelemetryCollectorSpec, error) {
+	if err := configure(resources); err != nil {
+		return nil, err
+	}
+
+	return applyPatches(&resources.OpenTelemetryCollector.Spec, resources.OpenTelemetryCollector.Name, resources.Patches)
+}
+
+// templateOtelColSpec configures the collector template with the secrets,
+// CA bundles and ConfigMaps of its exporters, the attributes of the cluster
+// and its sampling policy. Volumes and mounts that conflict with the ones of
+// the template are reported as a ValidationError.
+func templateOtelColSpec(resources Options) error {
+	spec := &resources.OpenTelemetryCollector.Spec
+
+	root, err := volumeMountRoot(resources)
+	if err != nil {
+		return err
+	}
+
+	var findings []string
+	conflict := func(err error) error {
+		var verr *otelcol.VolumeConflictError
+		if errors.As(err, &verr) {
+			findings = append(findings, verr.Error())
+			return nil
+		}
+		return err
+	}
+
+	trustBundleDir := ""
+	if len(resources.CABundles) > 0 {
+		trustBundleDir, err = configureTrustBundleVolume(spec, root)
+		if err := conflict(err); err != nil {
+			return err
+		}
+	}
+
 	for _, secret := range resources.Secrets {
-		if err := templateWithSecret(&resources.OpenTelemetryCollector.Spec, secret); err != nil {
-			return nil, err
+		caFile := ""
+		target := authentication.Target(secret.Annotations[AnnotationTargetOutputName])
+		if _, ok := resources.CABundles[target]; ok && trustBundleDir != "" {
+			caFile = path.Join(trustBundleDir, authentication.TrustBundleKey(target))
+		}
+
+		if err := conflict(templateWithSecret(spec, secret, root, caFile)); err != nil {
+			return err
 		}
 	}
 
+	if len(findings) > 0 {
+		return newValidationError(findings)
+	}
+
 	for _, configmap := range resources.ConfigMaps {
 		if err := templateWithConfigMap(&resources, configmap); err != nil {
-			return nil, err
+			return err
 		}
 	}
 
-	return &resources.OpenTelemetryCollector.Spec, nil
+	if err := configureClusterAttributes(resources); err != nil {
+		return err
+	}
+
+	return configureSampling(resources)
+}
+
+// volumeMountRoot returns the directory where the volumes injected by the
+// addon are mounted, it must be an absolute path.
+func volumeMountRoot(resources Options) (string, error) {
+	root := addon.CustomizedVariable(resources.AddOnDeploymentConfig, volumeMountRootKey)
+	if root == "" {
+		return defaultVolumeMountRoot, nil
+	}
+	if !path.IsAbs(root) {
+		return "", newValidationError([]string{fmt.Sprintf("volume mount root %q is not an absolute path", root)})
+	}
+	return path.Clean(root), nil
+}
+
+// configureTrustBundleVolume mounts the trust bundle ConfigMap and returns the
+// directory holding the CA bundles.
+func configureTrustBundleVolume(spec *otelv1alpha1.OpenTelemetryCollectorSpec, root string) (string, error) {
+	volume, err := otelcol.ConfigureTrustBundleVolume(spec, authentication.TrustBundleName)
+	if err != nil {
+		return "", err
+	}
+	return otelcol.ConfigureTrustBundleVolumeMount(spec, volume, root)
 }
 
-func templateWithSecret(spec *otelv1alpha1.OpenTelemetryCollectorSpec, secret corev1.Secret) error {
+// templateAgentOtelColSpec configures the agent collector template to run on
+// every node, add the attributes of the pods sending the spans and forward
+// them to the gateway collector. Credentials, cluster attributes and sampling
+// are handled by the gateway, with tail sampling the spans are load balanced
+// by trace ID so that each trace is sampled by a single gateway replica.
+func templateAgentOtelColSpec(resources Options) error {
+	spec := &resources.OpenTelemetryCollector.Spec
+	spec.Mode = otelv1alpha1.ModeDaemonSet
+
 	cfg, err := otelcol.ConfigFromString(spec.Config)
 	if err != nil {
-		return nil
+		return err
 	}
 
-	// iblancasa: add verifications for the exporters
+	otelcol.ConfigureK8sAttributes(cfg)
+	otelcol.ConfigureK8sAttributesNodeFilter(spec, cfg)
+	if useTailSampling(resources) {
+		otelcol.ConfigureAgentLoadBalancingExporter(cfg, collectorHeadlessService, otlpGRPCPort)
+	} else {
+		otelcol.ConfigureAgentExporter(cfg, fmt.Sprintf("%s:%d", collectorService, otlpGRPCPort))
+	}
+
+	spec.Config, err = otelcol.ConfigToString(cfg)
+	return err
+}
+
+// applyPatches applies the patches targeting the collector to its templated
+// spec. The collector configuration is patched as an object instead of the
+// YAML string stored in the spec so that patches can reach its components.
+// Strategic merge patches are applied as JSON merge patches.
+func applyPatches(spec *otelv1alpha1.OpenTelemetryCollectorSpec, name string, patches []patch.Patch) (*otelv1alpha1.OpenTelemetryCollectorSpec, error) {
+	if len(patches) == 0 {
+		return spec, nil
+	}
 
-	err = otelcol.ConfigureExportersSecrets(cfg, secret, AnnotationTargetOutputName)
+	cfg, err := otelcol.ConfigFromString(spec.Config)
+	if err != nil {
+		return nil, err
+	}
+	cfgObj, err := otelcol.ConfigToObject(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	doc["config"] = cfgObj
+
+	b, err = json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err = patch.Apply(b, name, patches, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	doc = map[string]interface{}{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+
+	cfg, err = otelcol.ConfigFromObject(doc["config"])
+	if err != nil {
+		return nil, kverrors.Wrap(err, "patched OpenTelemetryCollector config is invalid", "name", name)
+	}
+	doc["config"], err = otelcol.ConfigToString(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err = json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	patched := &otelv1alpha1.OpenTelemetryCollectorSpec{}
+	if err := json.Unmarshal(b, patched); err != nil {
+		return nil, kverrors.Wrap(err, "patched OpenTelemetryCollector spec is invalid", "name", name)
+	}
+	return patched, nil
+}
+
+func templateWithSecret(spec *otelv1alpha1.OpenTelemetryCollectorSpec, secret corev1.Secret, root, caFile string) error {
+	cfg, err := otelcol.ConfigFromString(spec.Config)
+	if err != nil {
+		return err
+	}
+
+	volume, err := otelcol.ConfigureVolumes(spec, secret)
+	if err != nil {
+		return err
+	}
+	folder, err := otelcol.ConfigureVolumeMounts(spec, volume, root)
 	if err != nil {
 		return err
 	}
 
-	yamlConfig, err := yaml.Marshal(&cfg)
+	err = otelcol.ConfigureExportersSecrets(cfg, secret, folder, caFile, AnnotationTargetOutputName)
 	if err != nil {
-		return kverrors.New(fmt.Sprint("error while marshaling OTEL Configuration: %w", err))
+		return err
 	}
-	spec.Config = string(yamlConfig)
 
-	otelcol.ConfigureVolumes(spec, secret)
-	otelcol.ConfigureVolumeMounts(spec, secret)
+	err = otelcol.ConfigureSecretHeaders(spec, cfg, secret, AnnotationTargetOutputName)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	spec.Config, err = otelcol.ConfigToString(cfg)
+	return err
 }
 
 func templateWithConfigMap(resource *Options, configmap corev1.ConfigMap) error {
@@ -73,15 +247,11 @@ func templateWithConfigMap(resource *Options, configmap corev1.ConfigMap) error
 	if err != nil {
 		return err
 	}
-	err = otelcol.ConfigureExporters(cfg, configmap, resource.ClusterName, AnnotationTargetOutputName)
+	err = otelcol.ConfigureExporters(cfg, configmap, addon.NewClusterValues(resource.ClusterName, resource.ManagedCluster), AnnotationTargetOutputName)
 	if err != nil {
 		return err
 	}
 
-	yamlConfig, err := yaml.Marshal(&cfg)
-	if err != nil {
-		return kverrors.New(fmt.Sprint("error while marshaling OTEL Configuration: %w", err))
-	}
-	resource.OpenTelemetryCollector.Spec.Config = string(yamlConfig)
-	return nil
+	resource.OpenTelemetryCollector.Spec.Config, err = otelcol.ConfigToString(cfg)
+	return err
 }
@@ -0,0 +1,106 @@
+package manifests
+
+import (
+	"errors"
+	"testing"
+
+	otelv1alpha1 "github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
+	"github.com/rhobs/multicluster-observability-addon/internal/tracing/manifests/otelcol"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+func Test_TemplateOtelColSpec_Volumes(t *testing.T) {
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "tracing-otlphttp-auth",
+			Annotations: map[string]string{
+				AnnotationTargetOutputName: "otlphttp",
+			},
+		},
+	}
+
+	newResources := func(root string, mounts ...corev1.VolumeMount) Options {
+		resources := Options{
+			Secrets: []corev1.Secret{secret},
+			OpenTelemetryCollector: &otelv1alpha1.OpenTelemetryCollector{
+				Spec: otelv1alpha1.OpenTelemetryCollectorSpec{
+					Config:       validationConfig,
+					VolumeMounts: mounts,
+				},
+			},
+			CABundles: authentication.CABundles{"otlphttp": "ca"},
+		}
+		if root != "" {
+			resources.AddOnDeploymentConfig = &addonapiv1alpha1.AddOnDeploymentConfig{
+				Spec: addonapiv1alpha1.AddOnDeploymentConfigSpec{
+					CustomizedVariables: []addonapiv1alpha1.CustomizedVariable{
+						{Name: volumeMountRootKey, Value: root},
+					},
+				},
+			}
+		}
+		return resources
+	}
+
+	for _, tc := range []struct {
+		name         string
+		resources    Options
+		wantCertFile string
+		wantCAFile   string
+		wantFindings []string
+	}{
+		{
+			name:         "default root",
+			resources:    newResources(""),
+			wantCertFile: "/tracing-otlphttp-auth/tls.crt",
+			wantCAFile:   "/mcoa-trust-bundle/otlphttp.crt",
+		},
+		{
+			name:         "custom root",
+			resources:    newResources("/var/run/mcoa/"),
+			wantCertFile: "/var/run/mcoa/tracing-otlphttp-auth/tls.crt",
+			wantCAFile:   "/var/run/mcoa/mcoa-trust-bundle/otlphttp.crt",
+		},
+		{
+			name:         "relative root",
+			resources:    newResources("mcoa"),
+			wantFindings: []string{`volume mount root "mcoa" is not an absolute path`},
+		},
+		{
+			name:      "conflicting mounts",
+			resources: newResources("/etc", corev1.VolumeMount{Name: "etc", MountPath: "/etc"}),
+			wantFindings: []string{
+				`mount path "/etc/mcoa-trust-bundle" of volume "mcoa-trust-bundle" overlaps with the mount of volume "etc"`,
+				`mount path "/etc/tracing-otlphttp-auth" of volume "tracing-otlphttp-auth" overlaps with the mount of volume "etc"`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := templateOtelColSpec(tc.resources)
+			if tc.wantFindings != nil {
+				var verr *addon.ValidationError
+				require.True(t, errors.As(err, &verr))
+				require.Equal(t, tc.wantFindings, verr.Findings)
+				return
+			}
+			require.NoError(t, err)
+
+			spec := &tc.resources.OpenTelemetryCollector.Spec
+			cfg, err := otelcol.ConfigFromString(spec.Config)
+			require.NoError(t, err)
+			tls := cfg.Exporters["otlphttp"]["tls"].(map[string]interface{})
+			require.Equal(t, tc.wantCertFile, tls["cert_file"])
+			require.Equal(t, tc.wantCAFile, tls["ca_file"])
+
+			// Templating the result again doesn't add volumes or mounts
+			require.NoError(t, templateOtelColSpec(tc.resources))
+			require.Len(t, spec.Volumes, 2)
+			require.Len(t, spec.VolumeMounts, 2)
+		})
+	}
+}
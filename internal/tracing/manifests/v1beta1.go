@@ -0,0 +1,197 @@
+package manifests
+
+import (
+	"encoding/json"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	otelv1alpha1 "github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/patch"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// OpenTelemetryCollectorV1Beta1GVK is the OpenTelemetryCollector API where
+// the collector configuration is structured. The addon doesn't vendor its Go
+// types so the resource is handled as unstructured.
+var OpenTelemetryCollectorV1Beta1GVK = schema.GroupVersionKind{
+	Group:   otelv1alpha1.GroupVersion.Group,
+	Version: otelColAPIVersionV1Beta1,
+	Kind:    "OpenTelemetryCollector",
+}
+
+// UseV1Beta1API returns true when the AddOnDeploymentConfig selects the
+// opentelemetry.io/v1beta1 API for the collector template and the collector
+// rendered on the spokes.
+func UseV1Beta1API(resources Options) bool {
+	return addon.CustomizedVariable(resources.AddOnDeploymentConfig, otelColAPIVersionKey) == otelColAPIVersionV1Beta1
+}
+
+// ConvertConfig converts the configuration of the template to the API version
+// of the collectors rendered on the spokes. Hubs that don't convert between
+// the opentelemetry.io versions serve the templates with the configuration of
+// the version they were created with: YAML in v1alpha1 and structured in
+// v1beta1.
+func ConvertConfig(template *unstructured.Unstructured, useV1Beta1 bool) error {
+	cfg, ok, err := unstructured.NestedFieldNoCopy(template.Object, "spec", "config")
+	if err != nil || !ok || cfg == nil {
+		return err
+	}
+
+	switch cfg := cfg.(type) {
+	case string:
+		if !useV1Beta1 {
+			return nil
+		}
+		b, err := sigsyaml.YAMLToJSON([]byte(cfg))
+		if err != nil {
+			return kverrors.Wrap(err, "failed to convert OpenTelemetryCollector config", "name", template.GetName())
+		}
+		structured := map[string]interface{}{}
+		if err := utiljson.Unmarshal(b, &structured); err != nil {
+			return kverrors.Wrap(err, "failed to convert OpenTelemetryCollector config", "name", template.GetName())
+		}
+		return unstructured.SetNestedMap(template.Object, structured, "spec", "config")
+	case map[string]interface{}:
+		if useV1Beta1 {
+			return nil
+		}
+		b, err := yaml.Marshal(cfg)
+		if err != nil {
+			return kverrors.Wrap(err, "failed to convert OpenTelemetryCollector config", "name", template.GetName())
+		}
+		return unstructured.SetNestedField(template.Object, string(b), "spec", "config")
+	}
+	return kverrors.New("invalid OpenTelemetryCollector config", "name", template.GetName())
+}
+
+// v1beta1TemplatedSpec holds the fields of the collector spec that the addon
+// templates besides the configuration. They are the only ones converted
+// between v1beta1 and v1alpha1, the other fields of a v1beta1 template are
+// rendered unchanged.
+type v1beta1TemplatedSpec struct {
+	Mode         string               `json:"mode,omitempty"`
+	Env          []corev1.EnvVar      `json:"env,omitempty"`
+	Volumes      []corev1.Volume      `json:"volumes,omitempty"`
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+}
+
+// ConvertFromV1Beta1 returns the v1alpha1 collector templated by the addon
+// for a v1beta1 template, its structured configuration is serialized as YAML.
+func ConvertFromV1Beta1(template *unstructured.Unstructured) (*otelv1alpha1.OpenTelemetryCollector, error) {
+	otelCol := &otelv1alpha1.OpenTelemetryCollector{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        template.GetName(),
+			Namespace:   template.GetNamespace(),
+			Annotations: template.GetAnnotations(),
+		},
+	}
+
+	spec, _, err := unstructured.NestedMap(template.Object, "spec")
+	if err != nil {
+		return nil, kverrors.Wrap(err, "failed to read OpenTelemetryCollector spec", "name", template.GetName())
+	}
+
+	if cfg, ok := spec["config"]; ok && cfg != nil {
+		b, err := yaml.Marshal(cfg)
+		if err != nil {
+			return nil, kverrors.Wrap(err, "failed to convert OpenTelemetryCollector config", "name", template.GetName())
+		}
+		otelCol.Spec.Config = string(b)
+	}
+
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	templated := v1beta1TemplatedSpec{}
+	if err := json.Unmarshal(b, &templated); err != nil {
+		return nil, kverrors.Wrap(err, "failed to convert OpenTelemetryCollector spec", "name", template.GetName())
+	}
+	otelCol.Spec.Mode = otelv1alpha1.Mode(templated.Mode)
+	otelCol.Spec.Env = templated.Env
+	otelCol.Spec.Volumes = templated.Volumes
+	otelCol.Spec.VolumeMounts = templated.VolumeMounts
+
+	return otelCol, nil
+}
+
+// convertToV1Beta1 returns the spec of the v1beta1 template with the fields
+// templated in the v1alpha1 spec.
+func convertToV1Beta1(template *unstructured.Unstructured, spec *otelv1alpha1.OpenTelemetryCollectorSpec) (map[string]interface{}, error) {
+	out, _, err := unstructured.NestedMap(template.Object, "spec")
+	if err != nil {
+		return nil, kverrors.Wrap(err, "failed to read OpenTelemetryCollector spec", "name", template.GetName())
+	}
+	if out == nil {
+		out = map[string]interface{}{}
+	}
+
+	cfg := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(spec.Config), &cfg); err != nil {
+		return nil, kverrors.Wrap(err, "couldn't parse the opentelemetry-collector configuration")
+	}
+	out["config"] = cfg
+
+	b, err := json.Marshal(v1beta1TemplatedSpec{
+		Mode:         string(spec.Mode),
+		Env:          spec.Env,
+		Volumes:      spec.Volumes,
+		VolumeMounts: spec.VolumeMounts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	templated := map[string]interface{}{}
+	if err := json.Unmarshal(b, &templated); err != nil {
+		return nil, err
+	}
+	for _, field := range []string{"mode", "env", "volumes", "volumeMounts"} {
+		if value, ok := templated[field]; ok {
+			out[field] = value
+			continue
+		}
+		delete(out, field)
+	}
+
+	return out, nil
+}
+
+// buildV1Beta1OtelColSpec templates the v1beta1 collector with configure
+// through its v1alpha1 conversion and applies the patches to the v1beta1 spec.
+func buildV1Beta1OtelColSpec(resources Options, configure func(Options) error) (map[string]interface{}, error) {
+	if err := configure(resources); err != nil {
+		return nil, err
+	}
+
+	template := resources.OpenTelemetryCollectorV1Beta1
+	spec, err := convertToV1Beta1(template, &resources.OpenTelemetryCollector.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resources.Patches) == 0 {
+		return spec, nil
+	}
+
+	doc, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err = patch.Apply(doc, template.GetName(), resources.Patches, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	patched := map[string]interface{}{}
+	if err := json.Unmarshal(doc, &patched); err != nil {
+		return nil, kverrors.Wrap(err, "patched OpenTelemetryCollector spec is invalid", "name", template.GetName())
+	}
+	return patched, nil
+}
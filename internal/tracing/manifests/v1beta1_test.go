@@ -0,0 +1,65 @@
+package manifests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_ConvertConfig(t *testing.T) {
+	yamlConfig := "processors:\n    batch:\n        send_batch_size: 1000000\n"
+	structuredConfig := map[string]interface{}{
+		"processors": map[string]interface{}{
+			"batch": map[string]interface{}{
+				"send_batch_size": int64(1000000),
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		name       string
+		config     interface{}
+		useV1Beta1 bool
+		expected   interface{}
+	}{
+		{
+			name:       "v1alpha1 to v1beta1",
+			config:     yamlConfig,
+			useV1Beta1: true,
+			expected:   structuredConfig,
+		},
+		{
+			name:       "v1beta1 to v1alpha1",
+			config:     structuredConfig,
+			useV1Beta1: false,
+			expected:   yamlConfig,
+		},
+		{
+			name:       "v1beta1",
+			config:     structuredConfig,
+			useV1Beta1: true,
+			expected:   structuredConfig,
+		},
+		{
+			name:       "v1alpha1",
+			config:     yamlConfig,
+			useV1Beta1: false,
+			expected:   yamlConfig,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			template := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"spec": map[string]interface{}{
+						"config": tc.config,
+					},
+				},
+			}
+
+			err := ConvertConfig(template, tc.useV1Beta1)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, template.Object["spec"].(map[string]interface{})["config"])
+		})
+	}
+}
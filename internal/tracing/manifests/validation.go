@@ -0,0 +1,98 @@
+package manifests
+
+import (
+	"fmt"
+
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/rhobs/multicluster-observability-addon/internal/tracing/manifests/otelcol"
+)
+
+// newValidationError returns a ValidationError with the findings of
+// validating a templated OpenTelemetryCollector
+func newValidationError(findings []string) error {
+	return &addon.ValidationError{Kind: "OpenTelemetryCollector", Findings: findings}
+}
+
+// validateOtelColConfig checks the rendered collector configuration, that
+// the secrets and ConfigMaps annotated with an exporter reference an exporter
+// of the configuration and that the collector receives the spans of the
+// instrumented applications.
+func validateOtelColConfig(config string, resources Options) error {
+	cfg, err := otelcol.ConfigFromString(config)
+	if err != nil {
+		return newValidationError([]string{err.Error()})
+	}
+
+	findings := otelcol.Validate(cfg)
+
+	for _, secret := range resources.Secrets {
+		if name, ok := secret.Annotations[AnnotationTargetOutputName]; ok {
+			if _, ok := cfg.Exporters[name]; !ok {
+				findings = append(findings, fmt.Sprintf("secret %q references unknown exporter %q", secret.Name, name))
+			}
+		}
+	}
+
+	for _, cm := range resources.ConfigMaps {
+		if name, ok := cm.Annotations[AnnotationTargetOutputName]; ok {
+			if _, ok := cfg.Exporters[name]; !ok {
+				findings = append(findings, fmt.Sprintf("configmap %q references unknown exporter %q", cm.Name, name))
+			}
+		}
+	}
+
+	switch {
+	case resources.AgentOpenTelemetryCollector != nil && !otelcol.HasOTLPReceiver(cfg):
+		findings = append(findings, "agent collectors require an otlp receiver in the collector")
+	case resources.AgentOpenTelemetryCollector == nil && useInstrumentation(resources):
+		findings = append(findings, validateInstrumentationProtocols(cfg, "collector")...)
+	}
+
+	if len(findings) > 0 {
+		return newValidationError(findings)
+	}
+	return nil
+}
+
+// validateAgentOtelColConfig checks the rendered configuration of the agent
+// collectors and that they receive the spans of the instrumented
+// applications.
+func validateAgentOtelColConfig(config string, resources Options) error {
+	cfg, err := otelcol.ConfigFromString(config)
+	if err != nil {
+		return newValidationError([]string{fmt.Sprintf("agent collector: %s", err)})
+	}
+
+	var findings []string
+	for _, finding := range otelcol.Validate(cfg) {
+		findings = append(findings, fmt.Sprintf("agent collector: %s", finding))
+	}
+
+	if useInstrumentation(resources) {
+		findings = append(findings, validateInstrumentationProtocols(cfg, "agent collector")...)
+	}
+
+	if len(findings) > 0 {
+		return newValidationError(findings)
+	}
+	return nil
+}
+
+// validateInstrumentationProtocols checks that the collector receiving the
+// spans of the instrumented applications listens with OTLP over gRPC and over
+// HTTP on the ports the Instrumentation exports to.
+func validateInstrumentationProtocols(cfg *otelcol.Config, collector string) []string {
+	var findings []string
+	for _, protocol := range []struct {
+		name string
+		port int
+	}{
+		{name: otelcol.OTLPGRPC, port: otlpGRPCPort},
+		{name: otelcol.OTLPHTTP, port: otlpHTTPPort},
+	} {
+		if !otelcol.HasOTLPProtocol(cfg, protocol.name, protocol.port) {
+			findings = append(findings, fmt.Sprintf("instrumentation requires an otlp receiver in the %s with the %s protocol on port %d", collector, protocol.name, protocol.port))
+		}
+	}
+	return findings
+}
@@ -0,0 +1,81 @@
+package manifests
+
+import (
+	"errors"
+	"testing"
+
+	otelv1alpha1 "github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const validationConfig = `
+receivers:
+  otlp:
+exporters:
+  otlphttp:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      exporters: [otlphttp, debug]
+`
+
+func Test_ValidateOtelColConfig(t *testing.T) {
+	resources := Options{
+		Secrets: []corev1.Secret{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "tracing-otlphttp-auth",
+					Annotations: map[string]string{
+						AnnotationTargetOutputName: "otlphttp",
+					},
+				},
+			},
+		},
+		ConfigMaps: []corev1.ConfigMap{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "tracing-otlp-endpoint",
+					Annotations: map[string]string{
+						AnnotationTargetOutputName: "otlp",
+					},
+				},
+			},
+		},
+	}
+
+	err := validateOtelColConfig(validationConfig, resources)
+	require.Error(t, err)
+
+	var verr *addon.ValidationError
+	require.True(t, errors.As(err, &verr))
+	require.Equal(t, []string{
+		`pipeline "traces" references unknown exporter "debug"`,
+		`configmap "tracing-otlp-endpoint" references unknown exporter "otlp"`,
+	}, verr.Findings)
+}
+
+func Test_ValidateOtelColConfig_Agent(t *testing.T) {
+	resources := Options{
+		AgentOpenTelemetryCollector: &otelv1alpha1.OpenTelemetryCollector{},
+	}
+
+	err := validateOtelColConfig(jaegerConfig, resources)
+	var verr *addon.ValidationError
+	require.True(t, errors.As(err, &verr))
+	require.Equal(t, []string{"agent collectors require an otlp receiver in the collector"}, verr.Findings)
+
+	err = validateAgentOtelColConfig(`
+receivers:
+  jaeger:
+service:
+  pipelines:
+    traces:
+      receivers: [jaeger]
+`, resources)
+	require.True(t, errors.As(err, &verr))
+	require.Equal(t, []string{`agent collector: pipeline "traces" requires at least a receiver and an exporter`}, verr.Findings)
+}
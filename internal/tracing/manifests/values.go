@@ -3,13 +3,29 @@ package manifests
 import (
 	"encoding/json"
 
+	otelv1alpha1 "github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/klog/v2"
 )
 
 type TracingValues struct {
-	Enabled     bool          `json:"enabled"`
-	OTELColSpec string        `json:"otelColSpec"`
-	Secrets     []SecretValue `json:"secrets"`
+	Enabled     bool   `json:"enabled"`
+	OTELColSpec string `json:"otelColSpec"`
+	// AgentOTELColSpec is the spec of the optional agent collectors
+	AgentOTELColSpec string `json:"agentOtelColSpec"`
+	// OTELColAPIVersion is the opentelemetry.io API version of the collector
+	OTELColAPIVersion string        `json:"otelColAPIVersion"`
+	Secrets           []SecretValue `json:"secrets"`
+	TrustBundle       string        `json:"trustBundle"`
+	// ServiceAccountName is the ServiceAccount of the collector, granted the
+	// permissions required by the k8sattributes processor
+	ServiceAccountName string `json:"serviceAccountName"`
+	// InstrumentationSpec is rendered as an Instrumentation in each of the
+	// InstrumentationNamespaces
+	InstrumentationSpec       string   `json:"instrumentationSpec"`
+	InstrumentationNamespaces []string `json:"instrumentationNamespaces"`
 }
 
 type SecretValue struct {
@@ -22,24 +38,106 @@ func BuildValues(opts Options) (TracingValues, error) {
 		Enabled: true,
 	}
 
+	if err := configureTempoStackGateway(&opts); err != nil {
+		return values, err
+	}
+
 	secrets, err := buildSecrets(opts)
 	if err != nil {
 		return values, err
 	}
 	values.Secrets = secrets
 
-	klog.Info("Building OTEL Collector instance")
-	otelColSpec, err := buildOtelColSpec(opts)
+	trustBundle, err := authentication.BuildTrustBundle(opts.CABundles)
 	if err != nil {
 		return values, err
 	}
+	values.TrustBundle = trustBundle
 
-	b, err := json.Marshal(otelColSpec)
+	klog.Info("Building OTEL Collector instance")
+	values.OTELColAPIVersion = otelv1alpha1.GroupVersion.Version
+	if opts.OpenTelemetryCollectorV1Beta1 != nil {
+		values.OTELColAPIVersion = otelColAPIVersionV1Beta1
+	}
+
+	otelColSpec, config, serviceAccount, err := renderOtelColSpec(opts, templateOtelColSpec)
 	if err != nil {
 		return values, err
 	}
+	if err := validateOtelColConfig(config, opts); err != nil {
+		return values, err
+	}
+	values.OTELColSpec = otelColSpec
+	values.ServiceAccountName = serviceAccount
+	if values.ServiceAccountName == "" {
+		values.ServiceAccountName = defaultServiceAccountName
+	}
 
-	values.OTELColSpec = string(b)
+	if opts.AgentOpenTelemetryCollector != nil {
+		klog.Info("Building OTEL Collector agent instance")
+		agentOpts := opts
+		agentOpts.OpenTelemetryCollector = opts.AgentOpenTelemetryCollector
+		agentOpts.OpenTelemetryCollectorV1Beta1 = opts.AgentOpenTelemetryCollectorV1Beta1
+
+		otelColSpec, config, serviceAccount, err := renderOtelColSpec(agentOpts, templateAgentOtelColSpec)
+		if err != nil {
+			return values, err
+		}
+		if err := validateAgentOtelColConfig(config, opts); err != nil {
+			return values, err
+		}
+		values.AgentOTELColSpec = otelColSpec
+		// The k8sattributes processor runs in the agents
+		values.ServiceAccountName = serviceAccount
+		if values.ServiceAccountName == "" {
+			values.ServiceAccountName = defaultAgentServiceAccountName
+		}
+	}
+
+	if useInstrumentation(opts) {
+		b, err := json.Marshal(buildInstrumentationSpec(opts))
+		if err != nil {
+			return values, err
+		}
+		values.InstrumentationSpec = string(b)
+		values.InstrumentationNamespaces = instrumentationNamespaces(opts)
+	}
 
 	return values, nil
 }
+
+// renderOtelColSpec templates the collector of the resources with configure
+// and returns its JSON spec, for the API version of the template, along with
+// its configuration and ServiceAccount.
+func renderOtelColSpec(resources Options, configure func(Options) error) (string, string, string, error) {
+	var (
+		otelColSpec    interface{}
+		config         string
+		serviceAccount string
+	)
+	if resources.OpenTelemetryCollectorV1Beta1 != nil {
+		spec, err := buildV1Beta1OtelColSpec(resources, configure)
+		if err != nil {
+			return "", "", "", err
+		}
+		b, err := yaml.Marshal(spec["config"])
+		if err != nil {
+			return "", "", "", err
+		}
+		otelColSpec, config = spec, string(b)
+		serviceAccount, _, _ = unstructured.NestedString(spec, "serviceAccount")
+	} else {
+		spec, err := buildOtelColSpec(resources, configure)
+		if err != nil {
+			return "", "", "", err
+		}
+		otelColSpec, config = spec, spec.Config
+		serviceAccount = spec.ServiceAccount
+	}
+
+	b, err := json.Marshal(otelColSpec)
+	if err != nil {
+		return "", "", "", err
+	}
+	return string(b), config, serviceAccount, nil
+}
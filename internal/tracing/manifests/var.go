@@ -8,6 +8,48 @@ import (
 
 const (
 	AnnotationTargetOutputName = "tracing.mcoa.openshift.io/target-output-name"
+
+	ConditionTypeTracingConfigurationDegraded = "TracingConfigurationDegraded"
+	// ConditionTypeTracingInstrumentationDegraded reports the namespaces of
+	// the Instrumentation that don't exist on the cluster
+	ConditionTypeTracingInstrumentationDegraded = "TracingInstrumentationDegraded"
+
+	// defaultServiceAccountName is the ServiceAccount created by the
+	// OpenTelemetry operator for the spoke-otelcol collector when its template
+	// doesn't set one
+	defaultServiceAccountName = "spoke-otelcol-collector"
+
+	// collectorService is the Service created by the OpenTelemetry operator
+	// for the spoke-otelcol collector, it receives the spans of the
+	// instrumented applications
+	collectorService = "spoke-otelcol-collector.spoke-otelcol.svc"
+	// collectorHeadlessService is the headless Service created by the
+	// OpenTelemetry operator for the spoke-otelcol collector, it resolves to
+	// the addresses of its replicas
+	collectorHeadlessService = "spoke-otelcol-collector-headless.spoke-otelcol.svc"
+	// agentCollectorService is the Service rendered by the addon for the
+	// spoke-otelcol-agent collectors, its Local internal traffic policy routes
+	// the spans of the instrumented applications to the agent of their node
+	agentCollectorService = "spoke-otelcol-agent-local.spoke-otelcol.svc"
+	// defaultAgentServiceAccountName is the ServiceAccount created by the
+	// OpenTelemetry operator for the spoke-otelcol-agent collectors
+	defaultAgentServiceAccountName = "spoke-otelcol-agent-collector"
+	// instrumentationNamespacesKey is the AddOnDeploymentConfig variable
+	// listing, comma separated, the namespaces where the Instrumentation is
+	// rendered
+	instrumentationNamespacesKey = "tracingInstrumentationNamespaces"
+
+	// otelColAPIVersionKey is the AddOnDeploymentConfig variable selecting the
+	// OpenTelemetryCollector API version of the template and of the collector
+	// rendered on the spokes
+	otelColAPIVersionKey     = "tracingOpenTelemetryCollectorAPIVersion"
+	otelColAPIVersionV1Beta1 = "v1beta1"
+
+	// volumeMountRootKey is the AddOnDeploymentConfig variable setting the
+	// directory where the volumes of the exporters secrets and CA bundles are
+	// mounted when the template doesn't mount them already
+	volumeMountRootKey     = "tracingVolumeMountRoot"
+	defaultVolumeMountRoot = "/"
 )
 
 var AuthDefaultConfig = &authentication.Config{
@@ -15,7 +15,10 @@ import (
 	operatorsv1 "github.com/operator-framework/api/pkg/operators/v1"
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/rhobs/multicluster-observability-addon/internal/addon"
+	"github.com/rhobs/multicluster-observability-addon/internal/addon/authentication"
 	addonhelm "github.com/rhobs/multicluster-observability-addon/internal/addon/helm"
+	lmanifests "github.com/rhobs/multicluster-observability-addon/internal/logging/manifests"
+	tmanifests "github.com/rhobs/multicluster-observability-addon/internal/tracing/manifests"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -32,6 +35,9 @@ import (
 	"open-cluster-management.io/addon-framework/pkg/version"
 	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	addonv1alpha1client "open-cluster-management.io/api/client/addon/clientset/versioned"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 )
@@ -70,6 +76,7 @@ func newCommand() *cobra.Command {
 	}
 
 	cmd.AddCommand(newControllerCommand())
+	cmd.AddCommand(newRevokeCommand())
 
 	return cmd
 }
@@ -84,6 +91,47 @@ func newControllerCommand() *cobra.Command {
 	return cmd
 }
 
+func newRevokeCommand() *cobra.Command {
+	var clusterName string
+
+	cmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "Revoke all the credentials issued for a managed cluster",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if clusterName == "" {
+				return fmt.Errorf("the --cluster flag is required")
+			}
+
+			kubeConfig, err := ctrl.GetConfig()
+			if err != nil {
+				return err
+			}
+
+			// Necessary to list and delete cert-manager resources
+			err = certmanagerv1.AddToScheme(scheme.Scheme)
+			if err != nil {
+				return err
+			}
+
+			// Necessary to annotate the ManagedCluster
+			err = clusterv1.AddToScheme(scheme.Scheme)
+			if err != nil {
+				return err
+			}
+
+			k8sClient, err := client.New(kubeConfig, client.Options{Scheme: scheme.Scheme})
+			if err != nil {
+				return err
+			}
+
+			return authentication.RequestRevocation(cmd.Context(), k8sClient, clusterName, addonhelm.SharedSecrets())
+		},
+	}
+	cmd.Flags().StringVar(&clusterName, "cluster", "", "Name of the managed cluster whose credentials will be revoked")
+
+	return cmd
+}
+
 func runController(ctx context.Context, kubeConfig *rest.Config) error {
 	addonClient, err := addonv1alpha1client.NewForConfig(kubeConfig)
 	if err != nil {
@@ -135,6 +183,18 @@ func runController(ctx context.Context, kubeConfig *rest.Config) error {
 		return err
 	}
 
+	// Necessary to record the revocation of credentials on ManagedClusters
+	err = clusterv1.AddToScheme(scheme.Scheme)
+	if err != nil {
+		return err
+	}
+
+	// Necessary to read the status of the addon ManifestWorks
+	err = workv1.AddToScheme(scheme.Scheme)
+	if err != nil {
+		return err
+	}
+
 	httpClient, err := rest.HTTPClientFor(kubeConfig)
 	if err != nil {
 		return err
@@ -161,17 +221,23 @@ func runController(ctx context.Context, kubeConfig *rest.Config) error {
 		addonfactory.ToAddOnCustomizedVariableValues,
 	)
 
+	// The chart renders Logging 6 ClusterLogForwarders and v1beta1
+	// OpenTelemetryCollectors that are handled as unstructured
+	renderScheme := addon.NewRenderScheme(scheme.Scheme, lmanifests.ObservabilityClusterLogForwarderGVK, tmanifests.OpenTelemetryCollectorV1Beta1GVK)
+
 	mcoaAgentAddon, err := addonfactory.NewAgentAddonFactory(addon.Name, addon.FS, "manifests/charts/mcoa").
 		WithConfigGVRs(
 			schema.GroupVersionResource{Version: "v1", Resource: "secrets"},
 			schema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
 			schema.GroupVersionResource{Version: "v1", Group: "logging.openshift.io", Resource: "clusterlogforwarders"},
+			schema.GroupVersionResource{Version: "v1", Group: "observability.openshift.io", Resource: "clusterlogforwarders"},
 			schema.GroupVersionResource{Version: "v1alpha1", Group: "opentelemetry.io", Resource: "opentelemetrycollectors"},
+			schema.GroupVersionResource{Version: "v1alpha1", Group: "opentelemetry.io", Resource: "instrumentations"},
 			utils.AddOnDeploymentConfigGVR,
 		).
 		WithGetValuesFuncs(addonConfigValuesFn, addonhelm.GetValuesFunc(k8sClient)).
 		WithAgentRegistrationOption(registrationOption).
-		WithScheme(scheme.Scheme).
+		WithScheme(renderScheme).
 		BuildHelmAgentAddon()
 	if err != nil {
 		klog.Errorf("failed to build agent %v", err)